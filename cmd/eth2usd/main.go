@@ -2,65 +2,221 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/eth"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/network"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/transport"
 	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/transport/cli"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/transport/exporter"
+	httptransport "github.com/dayanaadylkhanova/eth2usd/internal/adapter/transport/http"
 	"github.com/dayanaadylkhanova/eth2usd/internal/app"
+	"github.com/dayanaadylkhanova/eth2usd/internal/service"
 	"github.com/dayanaadylkhanova/eth2usd/pkg/logger"
 )
 
 func main() {
 	var (
-		rpcURL   string
-		registry string
-		tokens   string
-		account  string
-		format   string
-		output   string
-		timeout  time.Duration
+		rpcURL       string
+		netName      string
+		registry     string
+		tokens       stringList
+		sources      string
+		pricesFile   string
+		nftFile      string
+		floorsFile   string
+		twapWindow   time.Duration
+		weth         string
+		accounts     stringList
+		accountsFile string
+		block        string
+		at           string
+		positions    string
+		aaveProvider string
+		comets       stringList
+		npm          string
+		multicall    bool
+		multicallAdr string
+		concurrency  int
+		quote        string
+		quoteFeed    string
+		rounding     string
+		heartbeat    time.Duration
+		checkBounds  bool
+		discover     bool
+		discFrom     uint64
+		discChunk    uint64
+		strict       bool
+		failOn       string
+		format       string
+		csvDelim     string
+		csvHeader    bool
+		output       string
+		timeout      time.Duration
+		mode         string
+		listen       string
+		interval     time.Duration
+		watch        time.Duration
 	)
 	flag.StringVar(&rpcURL, "rpc-url", "http://localhost:8545", "Ethereum JSON-RPC endpoint")
-	flag.StringVar(&registry, "chainlink-registry", "", "Chainlink Feed Registry address (required)")
-	flag.StringVar(&tokens, "tokens-file", "", "Path to tokens whitelist JSON (overrides defaults)")
-	flag.StringVar(&account, "account", "", "Account address to read balances from (required)")
-	flag.StringVar(&format, "format", "text", "Output format: text|json")
+	flag.StringVar(&netName, "network", "", "Expected network: "+strings.Join(network.Names(), "|")+" (default: whatever the RPC is on)")
+	flag.StringVar(&registry, "chainlink-registry", "", "Chainlink Feed Registry address (mainnet only; defaults to the mainnet registry)")
+	flag.Var(&tokens, "tokens-file", "Token file (JSON array or tokenlists.org list) or builtin:<name>; repeatable, later ones override")
+	flag.StringVar(&sources, "price-sources", strings.Join(transport.DefaultPriceSources, ","), "Ordered price source fallback chain: aggregator,registry,wrapper,uniswap,static")
+	flag.StringVar(&pricesFile, "prices-file", "", "JSON object of static USD prices by token address or symbol")
+	flag.StringVar(&nftFile, "nft-collections", "", "JSON array of ERC-721/ERC-1155 collections to list holdings of")
+	flag.StringVar(&floorsFile, "nft-floors", "", "JSON object of NFT floor prices (ETH or USD) by collection address")
+	flag.DurationVar(&twapWindow, "twap-window", 30*time.Minute, "Uniswap V3 TWAP window")
+	flag.StringVar(&weth, "weth", "", "WETH address; Uniswap pools quoted in it are priced via ETH/USD (default from the network preset)")
+	flag.Var(&accounts, "account", "Account address to read balances from (repeatable)")
+	flag.StringVar(&accountsFile, "accounts-file", "", "File with account addresses, one per line")
+	flag.BoolVar(&discover, "discover", false, "Also value tokens found in Transfer logs to the accounts (non-zero balances only)")
+	flag.Uint64Var(&discFrom, "discover-from", 0, "First block scanned by --discover (required with it)")
+	flag.Uint64Var(&discChunk, "discover-chunk", eth.DefaultLogChunk, "Initial eth_getLogs block range; shrinks when the node rejects it")
+	flag.StringVar(&block, "block", "", "Block to value at: number, hash, latest|finalized|safe (default latest)")
+	flag.StringVar(&at, "at", "", "Value at the last block not after this time (RFC3339 or unix seconds)")
+	flag.StringVar(&positions, "positions", "", "Positions to value: aave,compound,uniswap-v2,uniswap-v3 (borrows are negative)")
+	flag.StringVar(&aaveProvider, "aave-data-provider", "", "Aave V3 PoolDataProvider address (default from the network preset)")
+	flag.Var(&comets, "comet", "Compound V3 market address (repeatable; default from the network preset)")
+	flag.StringVar(&npm, "uniswap-v3-position-manager", "", "Uniswap V3 NonfungiblePositionManager address (default from the network preset)")
+	flag.BoolVar(&multicall, "multicall", false, "Batch on-chain reads through Multicall3")
+	flag.StringVar(&multicallAdr, "multicall-address", eth.Multicall3Address, "Multicall3 contract address")
+	flag.IntVar(&concurrency, "concurrency", 1, "Number of tokens valued in parallel")
+	flag.StringVar(&quote, "quote", "USD", "Output currency: USD, EUR, GBP, JPY, ETH, BTC, ...")
+	flag.StringVar(&quoteFeed, "quote-feed", "", "QUOTE/USD Chainlink aggregator for --quote (needed without the registry)")
+	flag.StringVar(&rounding, "rounding", "half-even", "Rounding of printed amounts and values: half-even|half-away|truncate")
+	flag.DurationVar(&heartbeat, "heartbeat", service.DefaultHeartbeat, "Default max price age before a row is flagged stale")
+	flag.BoolVar(&checkBounds, "check-bounds", true, "Flag Chainlink answers pinned at the aggregator's minAnswer/maxAnswer")
+	flag.BoolVar(&strict, "strict", false, "Exit non-zero on any stale, incomplete, out-of-bounds, unpriced, unreadable or misconfigured row")
+	flag.StringVar(&failOn, "fail-on", "", "Failure classes that make the run exit non-zero: stale,incomplete,bound,noprice,rpc,config")
+	flag.StringVar(&format, "format", "text", "Output format: text|json|csv|markdown")
+	flag.StringVar(&csvDelim, "csv-delimiter", ",", `CSV field delimiter: one character or "tab"`)
+	flag.BoolVar(&csvHeader, "csv-header", true, "Write the CSV header record")
 	flag.StringVar(&output, "out", "", "Output file (stdout if empty)")
-	flag.DurationVar(&timeout, "timeout", 30*time.Second, "Global timeout")
+	flag.DurationVar(&timeout, "timeout", 30*time.Second, "Global timeout (per request in http mode, per run in exporter and watch modes)")
+	flag.StringVar(&mode, "mode", "cli", "Run mode: cli|http|exporter")
+	flag.StringVar(&listen, "listen", ":8080", "HTTP listen address (http and exporter modes)")
+	flag.DurationVar(&interval, "interval", exporter.DefaultInterval, "Time between valuation runs (exporter mode)")
+	flag.DurationVar(&watch, "watch", 0, "Re-value every interval, or on new blocks over a ws:// RPC at most that often, printing only changes (cli mode)")
 	flag.Parse()
 
-	if registry == "" || account == "" {
-		log.Fatalf("--chainlink-registry and --account are required")
+	if (mode == "cli" || mode == "exporter") && len(accounts) == 0 && accountsFile == "" {
+		log.Fatalf("--account or --accounts-file is required")
+	}
+
+	if block != "" && at != "" {
+		log.Fatalf("--block and --at are mutually exclusive")
+	}
+	var atTime time.Time
+	if at != "" {
+		t, err := parseTime(at)
+		if err != nil {
+			log.Fatalf("invalid --at: %v", err)
+		}
+		atTime = t
 	}
 
 	l := logger.New("eth2usd")
-	runner := cli.NewCLIRunner(l)
+
+	var (
+		runner     app.Runner
+		runTimeout = timeout
+	)
+	switch mode {
+	case "cli":
+		runner = cli.NewCLIRunner(l)
+		if watch > 0 {
+			runTimeout = 0 // watches until signalled; timeout applies per run
+		}
+	case "http":
+		runner = httptransport.NewServerRunner(l)
+		runTimeout = 0 // the server runs until signalled; timeout applies per request
+	case "exporter":
+		runner = exporter.NewExporterRunner(l)
+		runTimeout = 0 // runs until signalled; timeout applies per valuation run
+	default:
+		log.Fatalf("unknown --mode %q", mode)
+	}
 
 	application := app.New(l, runner)
 
-	ctx, cancel := signalContext(context.Background(), timeout)
+	ctx, cancel := signalContext(context.Background(), runTimeout)
 	defer cancel()
 
 	cfg := app.RunConfig{
 		RPCURL:            rpcURL,
+		Network:           netName,
 		ChainlinkRegistry: registry,
-		TokensFile:        tokens,
-		Account:           account,
+		TokensFiles:       tokens,
+		PriceSources:      splitList(sources),
+		PricesFile:        pricesFile,
+		NFTCollections:    nftFile,
+		NFTFloors:         floorsFile,
+		TWAPWindow:        twapWindow,
+		WETH:              weth,
+		Accounts:          accounts,
+		AccountsFile:      accountsFile,
+		Discover:          discover,
+		DiscoverFrom:      discFrom,
+		DiscoverChunk:     discChunk,
+		Block:             block,
+		At:                atTime,
+		Positions:         splitList(positions),
+		AaveDataProvider:  aaveProvider,
+		Comets:            comets,
+		PositionManager:   npm,
+		Multicall:         multicall,
+		MulticallAddress:  multicallAdr,
+		Concurrency:       concurrency,
+		Quote:             strings.ToUpper(quote),
+		QuoteFeed:         quoteFeed,
+		Rounding:          rounding,
+		Heartbeat:         heartbeat,
+		CheckBounds:       checkBounds,
+		Strict:            strict,
+		FailOn:            failOn,
 		Format:            format,
+		CSVDelimiter:      csvDelim,
+		CSVNoHeader:       !csvHeader,
 		Output:            output,
+		Listen:            listen,
+		Interval:          interval,
+		Watch:             watch,
+		Timeout:           timeout,
 	}
 
 	if err := application.Start(ctx, cfg); err != nil {
+		var fe *service.FailureError
+		if errors.As(err, &fe) {
+			// machine-readable summary on stderr, distinct exit code per failure class
+			fmt.Fprintln(os.Stderr, fe.Summary())
+			cancel()
+			os.Exit(fe.ExitCode)
+		}
 		log.Fatalf("exit with error: %v", err)
 	}
 }
 
+// signalContext is cancelled on SIGINT/SIGTERM or after timeout (no deadline if timeout <= 0).
 func signalContext(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
-	ctx, cancel := context.WithTimeout(parent, timeout)
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(parent, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(parent)
+	}
 	ch := make(chan os.Signal, 1)
 	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
@@ -72,3 +228,32 @@ func signalContext(parent context.Context, timeout time.Duration) (context.Conte
 	}()
 	return ctx, cancel
 }
+
+// parseTime accepts RFC3339 or unix seconds.
+func parseTime(s string) (time.Time, error) {
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(sec, 0), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// stringList collects repeated string flags.
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ",") }
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
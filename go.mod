@@ -2,7 +2,10 @@ module github.com/dayanaadylkhanova/eth2usd
 
 go 1.24.7
 
-require github.com/ethereum/go-ethereum v1.16.5
+require (
+	github.com/ethereum/go-ethereum v1.16.5
+	go.uber.org/mock v0.6.0
+)
 
 require (
 	github.com/Microsoft/go-winio v0.6.2 // indirect
@@ -23,6 +26,6 @@ require (
 	github.com/tklauser/go-sysconf v0.3.12 // indirect
 	github.com/tklauser/numcpus v0.6.1 // indirect
 	golang.org/x/crypto v0.36.0 // indirect
-	golang.org/x/sync v0.12.0 // indirect
+	golang.org/x/sync v0.16.0 // indirect
 	golang.org/x/sys v0.36.0 // indirect
 )
@@ -0,0 +1,108 @@
+package aave
+
+import (
+	"embed"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Встраиваем ABI Aave V3 PoolDataProvider (только чтение позиций).
+//
+//go:embed abi/data_provider.json
+var providerFS embed.FS
+
+// DataProvider encodes/decodes calls to an Aave V3 PoolDataProvider.
+type DataProvider struct {
+	addr common.Address
+	abi  abi.ABI
+}
+
+// Reserve is one listed asset of the market.
+type Reserve struct {
+	Symbol       string
+	TokenAddress common.Address
+}
+
+// UserReserve is an account's position in one reserve, in underlying units.
+type UserReserve struct {
+	Supplied     *big.Int // aToken balance
+	StableDebt   *big.Int
+	VariableDebt *big.Int
+}
+
+// ReserveTokens are the ERC-20 tokens that represent positions in one reserve.
+type ReserveTokens struct {
+	AToken            common.Address
+	StableDebtToken   common.Address
+	VariableDebtToken common.Address
+}
+
+func NewDataProvider(address string) (*DataProvider, error) {
+	if !common.IsHexAddress(address) {
+		return nil, errors.New("aave data provider address is not hex: " + address)
+	}
+	abiBytes, err := providerFS.ReadFile("abi/data_provider.json")
+	if err != nil {
+		return nil, err
+	}
+	a, err := abi.JSON(strings.NewReader(string(abiBytes)))
+	if err != nil {
+		return nil, err
+	}
+	return &DataProvider{addr: common.HexToAddress(address), abi: a}, nil
+}
+
+func (p *DataProvider) Address() common.Address { return p.addr }
+
+func (p *DataProvider) PackGetAllReservesTokens() ([]byte, error) {
+	return p.abi.Pack("getAllReservesTokens")
+}
+
+func (p *DataProvider) UnpackGetAllReservesTokens(out []byte) ([]Reserve, error) {
+	res, err := p.abi.Unpack("getAllReservesTokens", out)
+	if err != nil || len(res) != 1 {
+		return nil, errors.New("unpack getAllReservesTokens")
+	}
+	reserves := *abi.ConvertType(res[0], new([]Reserve)).(*[]Reserve)
+	return reserves, nil
+}
+
+func (p *DataProvider) PackGetUserReserveData(asset, user common.Address) ([]byte, error) {
+	return p.abi.Pack("getUserReserveData", asset, user)
+}
+
+func (p *DataProvider) UnpackGetUserReserveData(out []byte) (UserReserve, error) {
+	res, err := p.abi.Unpack("getUserReserveData", out)
+	if err != nil || len(res) != 9 {
+		return UserReserve{}, errors.New("unpack getUserReserveData")
+	}
+	supplied, ok1 := res[0].(*big.Int)
+	stable, ok2 := res[1].(*big.Int)
+	variable, ok3 := res[2].(*big.Int)
+	if !ok1 || !ok2 || !ok3 {
+		return UserReserve{}, errors.New("unexpected getUserReserveData types")
+	}
+	return UserReserve{Supplied: supplied, StableDebt: stable, VariableDebt: variable}, nil
+}
+
+func (p *DataProvider) PackGetReserveTokensAddresses(asset common.Address) ([]byte, error) {
+	return p.abi.Pack("getReserveTokensAddresses", asset)
+}
+
+func (p *DataProvider) UnpackGetReserveTokensAddresses(out []byte) (ReserveTokens, error) {
+	res, err := p.abi.Unpack("getReserveTokensAddresses", out)
+	if err != nil || len(res) != 3 {
+		return ReserveTokens{}, errors.New("unpack getReserveTokensAddresses")
+	}
+	a, ok1 := res[0].(common.Address)
+	stable, ok2 := res[1].(common.Address)
+	variable, ok3 := res[2].(common.Address)
+	if !ok1 || !ok2 || !ok3 {
+		return ReserveTokens{}, errors.New("unexpected getReserveTokensAddresses types")
+	}
+	return ReserveTokens{AToken: a, StableDebtToken: stable, VariableDebtToken: variable}, nil
+}
@@ -0,0 +1,52 @@
+package accounts
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Load reads account addresses from a file: one address per line,
+// blank lines and lines starting with '#' are ignored.
+func Load(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		out = append(out, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Normalize validates addresses and drops duplicates, keeping the first occurrence order.
+func Normalize(in []string) ([]string, error) {
+	seen := make(map[common.Address]struct{}, len(in))
+	out := make([]string, 0, len(in))
+	for _, a := range in {
+		if !common.IsHexAddress(a) {
+			return nil, errors.New("invalid account address: " + a)
+		}
+		addr := common.HexToAddress(a)
+		if _, ok := seen[addr]; ok {
+			continue
+		}
+		seen[addr] = struct{}{}
+		out = append(out, addr.Hex())
+	}
+	return out, nil
+}
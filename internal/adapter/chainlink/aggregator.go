@@ -0,0 +1,94 @@
+package chainlink
+
+import (
+	"embed"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/dayanaadylkhanova/eth2usd/internal/entity"
+)
+
+// Встраиваем ABI AggregatorV3Interface.
+//
+//go:embed abi/aggregator_v3.json
+var aggregatorFS embed.FS
+
+// AggregatorV3 encodes/decodes calls to AggregatorV3Interface price feed proxies.
+// Unlike FeedRegistry it is not bound to an address: one instance serves every feed,
+// which is how feeds are reached on chains without a registry (L2s, testnets).
+type AggregatorV3 struct {
+	abi abi.ABI
+}
+
+func NewAggregatorV3() (*AggregatorV3, error) {
+	abiBytes, err := aggregatorFS.ReadFile("abi/aggregator_v3.json")
+	if err != nil {
+		return nil, err
+	}
+	a, err := abi.JSON(strings.NewReader(string(abiBytes)))
+	if err != nil {
+		return nil, err
+	}
+	return &AggregatorV3{abi: a}, nil
+}
+
+func (a *AggregatorV3) PackLatestRoundData() ([]byte, error) { return a.abi.Pack("latestRoundData") }
+
+func (a *AggregatorV3) PackDecimals() ([]byte, error) { return a.abi.Pack("decimals") }
+
+// DecodeLatestRoundData: возвращает полный раунд (roundId, answer, startedAt, updatedAt, answeredInRound).
+func (a *AggregatorV3) DecodeLatestRoundData(out []byte) (entity.Round, error) {
+	return decodeRound(a.abi, out)
+}
+
+func (a *AggregatorV3) UnpackDecimals(out []byte) (uint8, error) {
+	res, err := a.abi.Unpack("decimals", out)
+	if err != nil || len(res) != 1 {
+		return 0, errors.New("unpack decimals")
+	}
+	switch v := res[0].(type) {
+	case uint8:
+		return v, nil
+	case *big.Int:
+		return uint8(v.Uint64()), nil
+	default:
+		return 0, errors.New("unexpected decimals type")
+	}
+}
+
+// PackAggregator asks a proxy for its current underlying aggregator.
+func (a *AggregatorV3) PackAggregator() ([]byte, error) { return a.abi.Pack("aggregator") }
+
+func (a *AggregatorV3) UnpackAggregator(out []byte) (common.Address, error) {
+	res, err := a.abi.Unpack("aggregator", out)
+	if err != nil || len(res) != 1 {
+		return common.Address{}, errors.New("unpack aggregator")
+	}
+	addr, ok := res[0].(common.Address)
+	if !ok {
+		return common.Address{}, errors.New("unexpected aggregator type")
+	}
+	return addr, nil
+}
+
+// PackMinAnswer / PackMaxAnswer read the circuit-breaker bounds of an underlying (OCR) aggregator.
+func (a *AggregatorV3) PackMinAnswer() ([]byte, error) { return a.abi.Pack("minAnswer") }
+
+func (a *AggregatorV3) PackMaxAnswer() ([]byte, error) { return a.abi.Pack("maxAnswer") }
+
+// UnpackAnswerBound decodes minAnswer()/maxAnswer().
+func (a *AggregatorV3) UnpackAnswerBound(method string, out []byte) (*big.Int, error) {
+	res, err := a.abi.Unpack(method, out)
+	if err != nil || len(res) != 1 {
+		return nil, errors.New("unpack " + method)
+	}
+	v, _ := res[0].(*big.Int)
+	if v == nil {
+		return nil, errors.New("nil " + method)
+	}
+	return v, nil
+}
@@ -5,10 +5,11 @@ import (
 	"errors"
 	"math/big"
 	"strings"
-	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/dayanaadylkhanova/eth2usd/internal/entity"
 )
 
 // Встраиваем ABI Feed Registry.
@@ -38,19 +39,9 @@ func NewFeedRegistry(addr string) (*FeedRegistry, error) {
 
 func (r *FeedRegistry) Address() common.Address { return r.addr }
 
-// DecodeLatestRoundData: возвращает цену (answer) и updatedAt.
-func (r *FeedRegistry) DecodeLatestRoundData(out []byte) (*big.Int, time.Time, error) {
-	// latestRoundData returns: (roundId, answer, startedAt, updatedAt, answeredInRound)
-	res, err := r.abi.Unpack("latestRoundData", out)
-	if err != nil || len(res) != 5 {
-		return nil, time.Time{}, errors.New("unpack latestRoundData")
-	}
-	answer, _ := res[1].(*big.Int)
-	updated, _ := res[3].(*big.Int)
-	if answer == nil || updated == nil {
-		return nil, time.Time{}, errors.New("nil values")
-	}
-	return answer, time.Unix(updated.Int64(), 0), nil
+// DecodeLatestRoundData: возвращает полный раунд (roundId, answer, startedAt, updatedAt, answeredInRound).
+func (r *FeedRegistry) DecodeLatestRoundData(out []byte) (entity.Round, error) {
+	return decodeRound(r.abi, out)
 }
 
 func (r *FeedRegistry) PackLatestRoundData(base, quote common.Address) ([]byte, error) {
@@ -75,3 +66,20 @@ func (r *FeedRegistry) UnpackDecimals(out []byte) (uint8, error) {
 		return 0, errors.New("unexpected decimals type")
 	}
 }
+
+// PackGetFeed asks the registry for the aggregator currently serving (base, quote).
+func (r *FeedRegistry) PackGetFeed(base, quote common.Address) ([]byte, error) {
+	return r.abi.Pack("getFeed", base, quote)
+}
+
+func (r *FeedRegistry) UnpackGetFeed(out []byte) (common.Address, error) {
+	res, err := r.abi.Unpack("getFeed", out)
+	if err != nil || len(res) != 1 {
+		return common.Address{}, errors.New("unpack getFeed")
+	}
+	addr, ok := res[0].(common.Address)
+	if !ok {
+		return common.Address{}, errors.New("unexpected getFeed type")
+	}
+	return addr, nil
+}
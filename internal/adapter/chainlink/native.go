@@ -0,0 +1,32 @@
+package chainlink
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// NativeAsset describes the native currency of a chain: ETH on Ethereum and most L2s,
+// POL on Polygon, BNB on BNB Chain, AVAX on Avalanche.
+type NativeAsset struct {
+	Symbol   string
+	Decimals uint8
+	Feed     string // SYMBOL/USD AggregatorV3 proxy; empty means the Feed Registry (ETH only)
+}
+
+// NativeETH is the native asset of Ethereum and of chains without a preset.
+var NativeETH = NativeAsset{Symbol: ETH, Decimals: 18}
+
+// IsNative reports whether a token address marks the native asset.
+// "eth://native" is the historical spelling and works on every chain.
+func IsNative(address string) bool {
+	return address == ETHPseudoAddress || strings.EqualFold(address, "native")
+}
+
+// RegistryBase returns the Feed Registry base of the asset, if the registry knows it.
+func (n NativeAsset) RegistryBase() (common.Address, bool) {
+	if n.Symbol == "" {
+		return DenominationETH, true
+	}
+	return Denomination(n.Symbol)
+}
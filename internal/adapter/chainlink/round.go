@@ -0,0 +1,34 @@
+package chainlink
+
+import (
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+
+	"github.com/dayanaadylkhanova/eth2usd/internal/entity"
+)
+
+// decodeRound unpacks latestRoundData: (roundId, answer, startedAt, updatedAt, answeredInRound).
+func decodeRound(a abi.ABI, out []byte) (entity.Round, error) {
+	res, err := a.Unpack("latestRoundData", out)
+	if err != nil || len(res) != 5 {
+		return entity.Round{}, errors.New("unpack latestRoundData")
+	}
+	roundID, _ := res[0].(*big.Int)
+	answer, _ := res[1].(*big.Int)
+	started, _ := res[2].(*big.Int)
+	updated, _ := res[3].(*big.Int)
+	answeredIn, _ := res[4].(*big.Int)
+	if roundID == nil || answer == nil || started == nil || updated == nil || answeredIn == nil {
+		return entity.Round{}, errors.New("nil values")
+	}
+	return entity.Round{
+		RoundID:         roundID,
+		Answer:          answer,
+		StartedAt:       time.Unix(started.Int64(), 0),
+		UpdatedAt:       time.Unix(updated.Int64(), 0),
+		AnsweredInRound: answeredIn,
+	}, nil
+}
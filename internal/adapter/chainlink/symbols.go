@@ -1,6 +1,42 @@
 package chainlink
 
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
 const (
-	ETHPseudoAddress = "eth://native"
+	ETHPseudoAddress = "eth://native" // native asset of the connected chain, see NativeAsset
 	USD              = "USD"
+	ETH              = "ETH"
+	BTC              = "BTC"
 )
+
+// Feed Registry denominations (see Chainlink Denominations library).
+var (
+	DenominationUSD = common.HexToAddress("0x0000000000000000000000000000000000000348")
+	DenominationETH = common.HexToAddress("0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE")
+	DenominationBTC = common.HexToAddress("0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB")
+)
+
+// fiatCodes are ISO 4217 numeric codes; the registry uses address(code) as the fiat denomination.
+var fiatCodes = map[string]int64{
+	"USD": 840, "EUR": 978, "GBP": 826, "JPY": 392, "CHF": 756, "CAD": 124,
+	"AUD": 36, "CNY": 156, "KRW": 410, "SGD": 702, "NZD": 554, "INR": 356, "BRL": 986,
+}
+
+// Denomination returns the Feed Registry address for a currency symbol (fiat, ETH or BTC).
+func Denomination(symbol string) (common.Address, bool) {
+	switch symbol {
+	case ETH:
+		return DenominationETH, true
+	case BTC:
+		return DenominationBTC, true
+	}
+	code, ok := fiatCodes[symbol]
+	if !ok {
+		return common.Address{}, false
+	}
+	return common.BigToAddress(big.NewInt(code)), true
+}
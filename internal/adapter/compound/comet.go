@@ -0,0 +1,110 @@
+package compound
+
+import (
+	"embed"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Встраиваем ABI Compound V3 Comet (только чтение позиций).
+//
+//go:embed abi/comet.json
+var cometFS embed.FS
+
+// Comet encodes/decodes calls to Compound V3 markets; one instance serves every market.
+type Comet struct {
+	abi abi.ABI
+}
+
+// AssetInfo is the configuration of a collateral asset (CometCore.AssetInfo).
+type AssetInfo struct {
+	Offset                    uint8
+	Asset                     common.Address
+	PriceFeed                 common.Address
+	Scale                     uint64
+	BorrowCollateralFactor    uint64
+	LiquidateCollateralFactor uint64
+	LiquidationFactor         uint64
+	SupplyCap                 *big.Int
+}
+
+func NewComet() (*Comet, error) {
+	abiBytes, err := cometFS.ReadFile("abi/comet.json")
+	if err != nil {
+		return nil, err
+	}
+	a, err := abi.JSON(strings.NewReader(string(abiBytes)))
+	if err != nil {
+		return nil, err
+	}
+	return &Comet{abi: a}, nil
+}
+
+func (c *Comet) PackBaseToken() ([]byte, error) { return c.abi.Pack("baseToken") }
+
+func (c *Comet) UnpackBaseToken(out []byte) (common.Address, error) {
+	res, err := c.abi.Unpack("baseToken", out)
+	if err != nil || len(res) != 1 {
+		return common.Address{}, errors.New("unpack baseToken")
+	}
+	addr, ok := res[0].(common.Address)
+	if !ok {
+		return common.Address{}, errors.New("unexpected baseToken type")
+	}
+	return addr, nil
+}
+
+// PackBalanceOf encodes the supplied base balance of account.
+func (c *Comet) PackBalanceOf(account common.Address) ([]byte, error) {
+	return c.abi.Pack("balanceOf", account)
+}
+
+func (c *Comet) PackBorrowBalanceOf(account common.Address) ([]byte, error) {
+	return c.abi.Pack("borrowBalanceOf", account)
+}
+
+func (c *Comet) PackCollateralBalanceOf(account, asset common.Address) ([]byte, error) {
+	return c.abi.Pack("collateralBalanceOf", account, asset)
+}
+
+// UnpackAmount decodes the result of balanceOf, borrowBalanceOf or collateralBalanceOf.
+func (c *Comet) UnpackAmount(method string, out []byte) (*big.Int, error) {
+	res, err := c.abi.Unpack(method, out)
+	if err != nil || len(res) != 1 {
+		return nil, errors.New("unpack " + method)
+	}
+	v, ok := res[0].(*big.Int)
+	if !ok {
+		return nil, errors.New("unexpected " + method + " type")
+	}
+	return v, nil
+}
+
+func (c *Comet) PackNumAssets() ([]byte, error) { return c.abi.Pack("numAssets") }
+
+func (c *Comet) UnpackNumAssets(out []byte) (uint8, error) {
+	res, err := c.abi.Unpack("numAssets", out)
+	if err != nil || len(res) != 1 {
+		return 0, errors.New("unpack numAssets")
+	}
+	n, ok := res[0].(uint8)
+	if !ok {
+		return 0, errors.New("unexpected numAssets type")
+	}
+	return n, nil
+}
+
+func (c *Comet) PackGetAssetInfo(i uint8) ([]byte, error) { return c.abi.Pack("getAssetInfo", i) }
+
+func (c *Comet) UnpackGetAssetInfo(out []byte) (AssetInfo, error) {
+	res, err := c.abi.Unpack("getAssetInfo", out)
+	if err != nil || len(res) != 1 {
+		return AssetInfo{}, errors.New("unpack getAssetInfo")
+	}
+	info := *abi.ConvertType(res[0], new(AssetInfo)).(*AssetInfo)
+	return info, nil
+}
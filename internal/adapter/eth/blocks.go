@@ -0,0 +1,91 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Errors of a block request that no node could answer.
+var (
+	ErrInvalidBlockSpec = errors.New("invalid block spec")
+	ErrBeforeGenesis    = errors.New("timestamp is before genesis block")
+)
+
+// ResolveBlock turns a block spec into a concrete header so every read of a run
+// hits the same state. Accepted specs: "" / "latest", "finalized", "safe",
+// "earliest", a decimal or 0x-hex number, or a 32-byte block hash.
+func (c *Client) ResolveBlock(ctx context.Context, spec string) (*types.Header, error) {
+	spec = strings.TrimSpace(spec)
+	switch strings.ToLower(spec) {
+	case "", "latest":
+		return c.headerByNumber(ctx, nil)
+	case "finalized":
+		return c.headerByNumber(ctx, big.NewInt(int64(rpc.FinalizedBlockNumber)))
+	case "safe":
+		return c.headerByNumber(ctx, big.NewInt(int64(rpc.SafeBlockNumber)))
+	case "earliest":
+		return c.headerByNumber(ctx, big.NewInt(0))
+	}
+
+	if strings.HasPrefix(spec, "0x") && len(spec) == 2+2*common.HashLength {
+		return c.headerByHash(ctx, common.HexToHash(spec))
+	}
+
+	n, ok := new(big.Int).SetString(spec, 0)
+	if !ok || n.Sign() < 0 {
+		return nil, fmt.Errorf("%w %q", ErrInvalidBlockSpec, spec)
+	}
+	return c.headerByNumber(ctx, n)
+}
+
+// BlockAtTime finds the last block whose timestamp is <= t by binary search over headers.
+func (c *Client) BlockAtTime(ctx context.Context, t time.Time) (*types.Header, error) {
+	latest, err := c.headerByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	target := uint64(t.Unix())
+	if target >= latest.Time {
+		return latest, nil
+	}
+
+	lo, hi := uint64(0), latest.Number.Uint64()
+	genesis, err := c.headerByNumber(ctx, new(big.Int))
+	if err != nil {
+		return nil, err
+	}
+	if target < genesis.Time {
+		return nil, ErrBeforeGenesis
+	}
+
+	// invariant: header(lo).Time <= target < header(hi).Time
+	best := genesis
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		h, err := c.headerByNumber(ctx, new(big.Int).SetUint64(mid))
+		if err != nil {
+			return nil, err
+		}
+		if h.Time <= target {
+			lo, best = mid, h
+		} else {
+			hi = mid
+		}
+	}
+	return best, nil
+}
+
+// SubscribeNewHeads streams the header of every new block (eth_subscribe newHeads);
+// only WebSocket and IPC endpoints support subscriptions.
+func (c *Client) SubscribeNewHeads(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return c.Eth.SubscribeNewHead(ctx, ch)
+}
@@ -0,0 +1,113 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// TransferTopic is keccak256("Transfer(address,address,uint256)").
+var TransferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// DefaultLogChunk is the initial block range of one eth_getLogs request.
+const DefaultLogChunk uint64 = 10_000
+
+// TransferScanner finds the tokens an account has received by scanning Transfer logs.
+type TransferScanner struct {
+	c     *Client
+	chunk uint64
+}
+
+// NewTransferScanner scans chunk blocks per request; the range shrinks on provider limits.
+func NewTransferScanner(c *Client, chunk uint64) *TransferScanner {
+	if chunk == 0 {
+		chunk = DefaultLogChunk
+	}
+	return &TransferScanner{c: c, chunk: chunk}
+}
+
+// Tokens returns, per account, the contracts that emitted an ERC-20 Transfer to it
+// within [from, to], in first-seen order.
+func (s *TransferScanner) Tokens(ctx context.Context, accounts []common.Address, from, to uint64) (map[common.Address][]common.Address, error) {
+	if len(accounts) == 0 || from > to {
+		return nil, nil
+	}
+	recipients := make([]common.Hash, len(accounts))
+	for i, a := range accounts {
+		recipients[i] = common.BytesToHash(a.Bytes())
+	}
+
+	var (
+		out   = make(map[common.Address][]common.Address, len(accounts))
+		seen  = make(map[[2]common.Address]bool)
+		chunk = s.chunk
+	)
+	for start := from; start <= to; {
+		end := min(start+chunk-1, to)
+		logs, err := s.c.filterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(start),
+			ToBlock:   new(big.Int).SetUint64(end),
+			Topics:    [][]common.Hash{{TransferTopic}, nil, recipients},
+		})
+		if err != nil {
+			if ctx.Err() != nil || !tooManyLogs(err) || chunk == 1 {
+				return nil, err
+			}
+			// provider refused the range: retry the same start with half of it
+			chunk = max(chunk/2, 1)
+			continue
+		}
+
+		for _, l := range logs {
+			// ERC-721 uses the same signature with an indexed tokenId (4 topics)
+			if len(l.Topics) != 3 {
+				continue
+			}
+			rcpt := common.BytesToAddress(l.Topics[2].Bytes())
+			if seen[[2]common.Address{rcpt, l.Address}] {
+				continue
+			}
+			seen[[2]common.Address{rcpt, l.Address}] = true
+			out[rcpt] = append(out[rcpt], l.Address)
+		}
+
+		if end == to {
+			break
+		}
+		start = end + 1
+		// grow back towards the configured range after a successful request
+		chunk = min(chunk*2, s.chunk)
+	}
+	return out, nil
+}
+
+// tooManyLogs recognizes the errors of providers refusing a range that is too large or has
+// too many results; anything else (timeouts, dropped connections) is not retried smaller.
+// Wording differs between providers, so the JSON-RPC codes used for it count too:
+// -32005 (limit exceeded) and -32602 (invalid params, e.g. a range over the cap).
+func tooManyLogs(err error) bool {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && (rpcErr.ErrorCode() == -32005 || rpcErr.ErrorCode() == -32602) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{
+		"query returned more than",   // geth/erigon: query returned more than 10000 results
+		"block range",                // Infura, QuickNode: block range is too large / exceeds ...
+		"log response size exceeded", // Alchemy
+		"limit exceeded",             // Alchemy, QuickNode and others
+		"is limited to a",            // QuickNode: eth_getLogs is limited to a 10,000 range
+		"too many results",
+	} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
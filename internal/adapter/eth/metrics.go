@@ -0,0 +1,110 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// LatencyBuckets are the upper bounds, in seconds, of the RPC latency histogram.
+var LatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// RPCMetrics counts the RPC requests of a Client by method and records their latency.
+// Reads served from a CallCache are not requests and are not counted.
+type RPCMetrics struct {
+	mu      sync.Mutex
+	methods map[string]*RPCStat
+}
+
+// RPCStat is a snapshot of one method's counters.
+type RPCStat struct {
+	Method  string
+	Calls   uint64
+	Errors  uint64
+	Buckets []uint64 // cumulative counts per LatencyBuckets bound
+	Sum     float64  // total latency in seconds
+}
+
+func NewRPCMetrics() *RPCMetrics { return &RPCMetrics{methods: make(map[string]*RPCStat)} }
+
+func (m *RPCMetrics) observe(method string, start time.Time, err error) {
+	d := time.Since(start).Seconds()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.methods[method]
+	if !ok {
+		s = &RPCStat{Method: method, Buckets: make([]uint64, len(LatencyBuckets))}
+		m.methods[method] = s
+	}
+	s.Calls++
+	if err != nil {
+		s.Errors++
+	}
+	s.Sum += d
+	for i, le := range LatencyBuckets {
+		if d <= le {
+			s.Buckets[i]++
+		}
+	}
+}
+
+// Snapshot copies the counters, sorted by method.
+func (m *RPCMetrics) Snapshot() []RPCStat {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]RPCStat, 0, len(m.methods))
+	for _, s := range m.methods {
+		c := *s
+		c.Buckets = slices.Clone(s.Buckets)
+		out = append(out, c)
+	}
+	slices.SortFunc(out, func(a, b RPCStat) int { return strings.Compare(a.Method, b.Method) })
+	return out
+}
+
+// Metrics returns the RPC counters shared by the client and its copies.
+func (c *Client) Metrics() *RPCMetrics { return c.metrics }
+
+// --- instrumented requests; every RPC of the package goes through these ---
+
+func (c *Client) callContract(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	start := time.Now()
+	out, err := c.Eth.CallContract(ctx, msg, block)
+	c.metrics.observe("eth_call", start, err)
+	return out, err
+}
+
+func (c *Client) balanceAt(ctx context.Context, account common.Address, block *big.Int) (*big.Int, error) {
+	start := time.Now()
+	bal, err := c.Eth.BalanceAt(ctx, account, block)
+	c.metrics.observe("eth_getBalance", start, err)
+	return bal, err
+}
+
+func (c *Client) headerByNumber(ctx context.Context, n *big.Int) (*types.Header, error) {
+	start := time.Now()
+	h, err := c.Eth.HeaderByNumber(ctx, n)
+	c.metrics.observe("eth_getBlockByNumber", start, err)
+	return h, err
+}
+
+func (c *Client) headerByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	start := time.Now()
+	h, err := c.Eth.HeaderByHash(ctx, hash)
+	c.metrics.observe("eth_getBlockByHash", start, err)
+	return h, err
+}
+
+func (c *Client) filterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	start := time.Now()
+	logs, err := c.Eth.FilterLogs(ctx, q)
+	c.metrics.observe("eth_getLogs", start, err)
+	return logs, err
+}
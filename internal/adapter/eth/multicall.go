@@ -0,0 +1,186 @@
+package eth
+
+import (
+	"context"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"maps"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Multicall3 is deployed at the same address on virtually every EVM chain.
+const Multicall3Address = "0xcA11bde05977b3631167028862bE2a173976CA11"
+
+// defaultBatchSize keeps a single aggregate3 call well below common RPC gas/response limits.
+const defaultBatchSize = 300
+
+//go:embed abi/multicall3.json
+var multicallFS embed.FS
+
+// CallRequest is a single contract read to be batched.
+type CallRequest struct {
+	To   common.Address
+	Data []byte
+}
+
+type call3 struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+type call3Result struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// Multicall batches reads through Multicall3.aggregate3 with per-call failure tolerance.
+type Multicall struct {
+	c         *Client
+	addr      common.Address
+	abi       abi.ABI
+	batchSize int
+}
+
+func NewMulticall(c *Client, addr string) (*Multicall, error) {
+	if !common.IsHexAddress(addr) {
+		return nil, errors.New("invalid multicall address")
+	}
+	abiBytes, err := multicallFS.ReadFile("abi/multicall3.json")
+	if err != nil {
+		return nil, err
+	}
+	a, err := abi.JSON(strings.NewReader(string(abiBytes)))
+	if err != nil {
+		return nil, err
+	}
+	return &Multicall{c: c, addr: common.HexToAddress(addr), abi: a, batchSize: defaultBatchSize}, nil
+}
+
+// Prefetch executes calls and native balance reads in as few aggregate3 calls as possible
+// and returns a cache of the successful results. Failed sub-calls are simply absent from
+// the cache, so readers fall back to a direct eth_call for them. A failed batch does not
+// stop the remaining batches; the first such error is returned alongside the partial cache.
+func (m *Multicall) Prefetch(ctx context.Context, calls []CallRequest, native []common.Address, block *big.Int) (*CallCache, error) {
+	cache := newCallCache(block)
+
+	all := make([]CallRequest, 0, len(calls)+len(native))
+	all = append(all, calls...)
+	for _, acc := range native {
+		data, err := m.abi.Pack("getEthBalance", acc)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, CallRequest{To: m.addr, Data: data})
+	}
+
+	var firstErr error
+	for start := 0; start < len(all); start += m.batchSize {
+		if err := ctx.Err(); err != nil {
+			return cache, err
+		}
+		end := min(start+m.batchSize, len(all))
+		results, err := m.aggregate(ctx, all[start:end], block)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		for i, r := range results {
+			if !r.Success {
+				continue
+			}
+			req := all[start+i]
+			if req.To == m.addr {
+				if bal, err := m.unpackEthBalance(r.ReturnData); err == nil {
+					cache.balances[native[start+i-len(calls)]] = bal
+				}
+				continue
+			}
+			cache.calls[cacheKey(req.To, req.Data)] = r.ReturnData
+		}
+	}
+	return cache, firstErr
+}
+
+func (m *Multicall) aggregate(ctx context.Context, reqs []CallRequest, block *big.Int) ([]call3Result, error) {
+	in := make([]call3, len(reqs))
+	for i, r := range reqs {
+		in[i] = call3{Target: r.To, AllowFailure: true, CallData: r.Data}
+	}
+	data, err := m.abi.Pack("aggregate3", in)
+	if err != nil {
+		return nil, err
+	}
+	out, err := m.c.callContract(ctx, callMsg(m.addr, data), block)
+	if err != nil {
+		return nil, err
+	}
+	var results []call3Result
+	if err := m.abi.UnpackIntoInterface(&results, "aggregate3", out); err != nil {
+		return nil, err
+	}
+	if len(results) != len(reqs) {
+		return nil, errors.New("aggregate3: result count mismatch")
+	}
+	return results, nil
+}
+
+func (m *Multicall) unpackEthBalance(out []byte) (*big.Int, error) {
+	res, err := m.abi.Unpack("getEthBalance", out)
+	if err != nil || len(res) != 1 {
+		return nil, errors.New("bad getEthBalance unpack")
+	}
+	bal, _ := res[0].(*big.Int)
+	if bal == nil {
+		return nil, errors.New("nil balance")
+	}
+	return bal, nil
+}
+
+// CallCache holds prefetched results for one block.
+type CallCache struct {
+	block    *big.Int
+	calls    map[string][]byte
+	balances map[common.Address]*big.Int
+}
+
+func newCallCache(block *big.Int) *CallCache {
+	return &CallCache{
+		block:    block,
+		calls:    make(map[string][]byte),
+		balances: make(map[common.Address]*big.Int),
+	}
+}
+
+// Len reports how many results the cache holds.
+func (cc *CallCache) Len() int { return len(cc.calls) + len(cc.balances) }
+
+// Lookup returns the prefetched result of calling to with data, if any.
+func (cc *CallCache) Lookup(to common.Address, data []byte) ([]byte, bool) {
+	out, ok := cc.calls[cacheKey(to, data)]
+	return out, ok
+}
+
+// Merge adds the results of o, which must have been prefetched at the same block.
+func (cc *CallCache) Merge(o *CallCache) {
+	maps.Copy(cc.calls, o.calls)
+	maps.Copy(cc.balances, o.balances)
+}
+
+func (cc *CallCache) matches(block *big.Int) bool {
+	if cc.block == nil || block == nil {
+		return cc.block == nil && block == nil
+	}
+	return cc.block.Cmp(block) == 0
+}
+
+func cacheKey(to common.Address, data []byte) string {
+	return to.Hex() + ":" + hex.EncodeToString(data)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 // Встраиваем ABI ERC-20 из соседней папки.
@@ -21,7 +22,10 @@ var erc20FS embed.FS
 // Client wraps go-ethereum client + cached ABIs.
 type Client struct {
 	Eth      *ethclient.Client
+	ChainID  uint64 // eth_chainId, read on connect
 	erc20ABI abi.ABI
+	cache    *CallCache  // optional prefetched results, see WithCache
+	metrics  *RPCMetrics // shared by copies
 }
 
 func NewClient(ctx context.Context, endpoint string) (*Client, error) {
@@ -39,24 +43,77 @@ func NewClient(ctx context.Context, endpoint string) (*Client, error) {
 		c.Close()
 		return nil, err
 	}
-	return &Client{Eth: c, erc20ABI: erc}, nil
+	chainID, err := c.ChainID(ctx)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	return &Client{Eth: c, ChainID: chainID.Uint64(), erc20ABI: erc, metrics: NewRPCMetrics()}, nil
 }
 
 func (c *Client) Close() { c.Eth.Close() }
 
-func (c *Client) GetBalance(ctx context.Context, account common.Address) (*big.Int, error) {
-	return c.Eth.BalanceAt(ctx, account, nil)
+// WithCache returns a client sharing the same connection that serves reads from cache
+// when possible and falls back to RPC otherwise.
+func (c *Client) WithCache(cache *CallCache) *Client {
+	cp := *c
+	cp.cache = cache
+	return &cp
+}
+
+// GetBalance returns the native balance at block (nil means latest).
+func (c *Client) GetBalance(ctx context.Context, account common.Address, block *big.Int) (*big.Int, error) {
+	if c.cache != nil && c.cache.matches(block) {
+		if bal, ok := c.cache.balances[account]; ok {
+			return new(big.Int).Set(bal), nil
+		}
+	}
+	return c.balanceAt(ctx, account, block)
+}
+
+// Call performs eth_call against contract 'to' at block (nil means latest).
+func (c *Client) Call(ctx context.Context, to common.Address, data []byte, block *big.Int) ([]byte, error) {
+	if c.cache != nil && c.cache.matches(block) {
+		if out, ok := c.cache.calls[cacheKey(to, data)]; ok {
+			return out, nil
+		}
+	}
+	return c.callContract(ctx, callMsg(to, data), block)
+}
+
+// IsReverted reports whether a Call failed because the contract reverted, as opposed to
+// the node or the connection failing. Nodes answer code 3 with revert data, or a
+// plain "execution reverted" message without it.
+func IsReverted(err error) bool {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	return rpcErr.ErrorCode() == 3 || strings.Contains(rpcErr.Error(), "execution reverted")
+}
+
+func callMsg(to common.Address, data []byte) ethereum.CallMsg {
+	return ethereum.CallMsg{To: &to, Data: data}
 }
 
+// --- ERC20 call data, used to build multicall batches ---
+
+func (c *Client) PackERC20BalanceOf(account common.Address) ([]byte, error) {
+	return c.erc20ABI.Pack("balanceOf", account)
+}
+
+func (c *Client) PackERC20Decimals() ([]byte, error) { return c.erc20ABI.Pack("decimals") }
+
+func (c *Client) PackERC20Symbol() ([]byte, error) { return c.erc20ABI.Pack("symbol") }
+
 // --- ERC20 ---
 
-func (c *Client) ERC20BalanceOf(ctx context.Context, token, account common.Address) (*big.Int, error) {
+func (c *Client) ERC20BalanceOf(ctx context.Context, token, account common.Address, block *big.Int) (*big.Int, error) {
 	data, err := c.erc20ABI.Pack("balanceOf", account)
 	if err != nil {
 		return nil, err
 	}
-	msg := ethereum.CallMsg{To: &token, Data: data}
-	out, err := c.Eth.CallContract(ctx, msg, nil)
+	out, err := c.Call(ctx, token, data, block)
 	if err != nil {
 		return nil, err
 	}
@@ -71,13 +128,12 @@ func (c *Client) ERC20BalanceOf(ctx context.Context, token, account common.Addre
 	return balance, nil
 }
 
-func (c *Client) ERC20Decimals(ctx context.Context, token common.Address) (uint8, error) {
+func (c *Client) ERC20Decimals(ctx context.Context, token common.Address, block *big.Int) (uint8, error) {
 	data, err := c.erc20ABI.Pack("decimals")
 	if err != nil {
 		return 0, err
 	}
-	msg := ethereum.CallMsg{To: &token, Data: data}
-	out, err := c.Eth.CallContract(ctx, msg, nil)
+	out, err := c.Call(ctx, token, data, block)
 	if err != nil {
 		return 0, err
 	}
@@ -95,13 +151,12 @@ func (c *Client) ERC20Decimals(ctx context.Context, token common.Address) (uint8
 	}
 }
 
-func (c *Client) ERC20Symbol(ctx context.Context, token common.Address) (string, error) {
+func (c *Client) ERC20Symbol(ctx context.Context, token common.Address, block *big.Int) (string, error) {
 	data, err := c.erc20ABI.Pack("symbol")
 	if err != nil {
 		return "", err
 	}
-	msg := ethereum.CallMsg{To: &token, Data: data}
-	out, err := c.Eth.CallContract(ctx, msg, nil)
+	out, err := c.Call(ctx, token, data, block)
 	if err != nil {
 		return "", err
 	}
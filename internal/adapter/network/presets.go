@@ -0,0 +1,127 @@
+package network
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/chainlink"
+)
+
+// Preset describes the Chainlink deployment and defaults of a known network.
+type Preset struct {
+	Name    string
+	ChainID uint64
+
+	Native   chainlink.NativeAsset
+	ETHFeed  string // ETH/USD AggregatorV3 proxy, prices WETH and --quote ETH
+	Registry string // Chainlink Feed Registry; only deployed on mainnet
+	WETH     string // wrapped ETH, the quote token of most Uniswap pools
+
+	Tokens []string // default token sources, see tokens.Load; without them a run needs --tokens-file
+
+	AaveDataProvider string   // Aave V3 PoolDataProvider of the main market
+	Comets           []string // Compound V3 markets
+	PositionManager  string   // Uniswap V3 NonfungiblePositionManager
+}
+
+// Presets are the built-in networks.
+var Presets = []Preset{
+	{
+		Name: "mainnet", ChainID: 1,
+		Native:   chainlink.NativeAsset{Symbol: "ETH", Decimals: 18, Feed: "0x5f4eC3Df9cbd43714FE2740f5E3616155c5b8419"},
+		ETHFeed:  "0x5f4eC3Df9cbd43714FE2740f5E3616155c5b8419",
+		Registry: "0x47Fb2585D2C56Fe188D0E6ec628a38b74fCeeeDf",
+		WETH:     "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2",
+		Tokens:   []string{"builtin:mainnet"},
+
+		AaveDataProvider: "0x7B4EB56E7CD4b454BA8ff71E4518426369a138a3",
+		Comets: []string{
+			"0xc3d688B66703497DAA19211EEdff47f25384cdc3", // cUSDCv3
+			"0xA17581A9E3356d9A858b789D68B4d866e593aE94", // cWETHv3
+		},
+		PositionManager: "0xC36442b4a4522E871399CD717aBDD847Ab11FE88",
+	},
+	{
+		Name: "sepolia", ChainID: 11155111,
+		Native:  chainlink.NativeAsset{Symbol: "ETH", Decimals: 18, Feed: "0x694AA1769357215DE4FAC081bf1f309aDC325306"},
+		ETHFeed: "0x694AA1769357215DE4FAC081bf1f309aDC325306",
+		WETH:    "0xfFf9976782d46CC05630D1f6eBAb18b2324d6B14",
+	},
+	{
+		Name: "arbitrum", ChainID: 42161,
+		Native:  chainlink.NativeAsset{Symbol: "ETH", Decimals: 18, Feed: "0x639Fe6ab55C921f74e7fac1ee960C0B6293ba612"},
+		ETHFeed: "0x639Fe6ab55C921f74e7fac1ee960C0B6293ba612",
+		WETH:    "0x82aF49447D8a07e3bd95BD0d56f35241523fBab1",
+
+		PositionManager: "0xC36442b4a4522E871399CD717aBDD847Ab11FE88",
+	},
+	{
+		Name: "optimism", ChainID: 10,
+		Native:  chainlink.NativeAsset{Symbol: "ETH", Decimals: 18, Feed: "0x13e3Ee699D1909E989722E753853AE30b17e08c5"},
+		ETHFeed: "0x13e3Ee699D1909E989722E753853AE30b17e08c5",
+		WETH:    "0x4200000000000000000000000000000000000006",
+
+		PositionManager: "0xC36442b4a4522E871399CD717aBDD847Ab11FE88",
+	},
+	{
+		Name: "base", ChainID: 8453,
+		Native:  chainlink.NativeAsset{Symbol: "ETH", Decimals: 18, Feed: "0x71041dddad3595F9CEd3DcCFBe3D1F4b0a16Bb70"},
+		ETHFeed: "0x71041dddad3595F9CEd3DcCFBe3D1F4b0a16Bb70",
+		WETH:    "0x4200000000000000000000000000000000000006",
+
+		PositionManager: "0x03a520b32C04BF3bEEf7BEb72E919cf822Ed34f1",
+	},
+	{
+		Name: "polygon", ChainID: 137,
+		Native:  chainlink.NativeAsset{Symbol: "POL", Decimals: 18, Feed: "0xAB594600376Ec9fD91F8e885dADF0CE036862dE0"}, // MATIC/USD, the feed kept its name
+		ETHFeed: "0xF9680D99D6C9589e2a93a78A04A279e509205945",
+		WETH:    "0x7ceB23fD6bC0adD59E62ac25578270cFf1b9f619",
+
+		PositionManager: "0xC36442b4a4522E871399CD717aBDD847Ab11FE88",
+	},
+	{
+		Name: "bsc", ChainID: 56,
+		Native: chainlink.NativeAsset{Symbol: "BNB", Decimals: 18, Feed: "0x0567F2323251f0Aab15c8dFb1967E4e8A7D42aeE"},
+	},
+	{
+		Name: "avalanche", ChainID: 43114,
+		Native: chainlink.NativeAsset{Symbol: "AVAX", Decimals: 18, Feed: "0x0A77230d17318075983913bC2145DB16C7366156"},
+	},
+}
+
+// ByChainID finds the preset of a chain.
+func ByChainID(id uint64) (Preset, bool) {
+	for _, p := range Presets {
+		if p.ChainID == id {
+			return p, true
+		}
+	}
+	return Preset{}, false
+}
+
+// ByName finds a preset by its name, case-insensitively.
+func ByName(name string) (Preset, bool) {
+	for _, p := range Presets {
+		if strings.EqualFold(p.Name, name) {
+			return p, true
+		}
+	}
+	return Preset{}, false
+}
+
+// Describe names a chain for messages: "arbitrum (42161)" or "chain 1234".
+func Describe(id uint64) string {
+	if p, ok := ByChainID(id); ok {
+		return fmt.Sprintf("%s (%d)", p.Name, id)
+	}
+	return fmt.Sprintf("chain %d", id)
+}
+
+// Names lists the preset names.
+func Names() []string {
+	out := make([]string, len(Presets))
+	for i, p := range Presets {
+		out[i] = p.Name
+	}
+	return out
+}
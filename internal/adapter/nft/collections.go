@@ -0,0 +1,122 @@
+package nft
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Token standards of a collection.
+const (
+	ERC721  = "erc721"
+	ERC1155 = "erc1155"
+)
+
+// Collection is an NFT contract to look for holdings in.
+type Collection struct {
+	Address  string   `json:"address"`
+	Name     string   `json:"name,omitempty"`
+	Standard string   `json:"standard,omitempty"`  // erc721 (default) | erc1155
+	TokenIDs []string `json:"token_ids,omitempty"` // required for erc1155; for erc721 checked with ownerOf instead of enumerating
+}
+
+// Floor is the price of one token of a collection.
+type Floor struct {
+	Price    string `json:"price"`
+	Currency string `json:"currency,omitempty"` // ETH | USD; default ETH
+}
+
+// Floor currencies.
+const (
+	ETH = "ETH"
+	USD = "USD"
+)
+
+// LoadCollections reads a JSON array of Collections:
+//
+//	[{ "address": "0x57f1...eA85", "name": "ENS" },
+//	 { "address": "0x7604...", "standard": "erc1155", "token_ids": ["1", "2"] }]
+func LoadCollections(path string) ([]Collection, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var out []Collection
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	for i := range out {
+		c := &out[i]
+		if !common.IsHexAddress(c.Address) {
+			return nil, fmt.Errorf("collection %d: address is not hex: %q", i, c.Address)
+		}
+		c.Standard = strings.ToLower(c.Standard)
+		if c.Standard == "" {
+			c.Standard = ERC721
+		}
+		if c.Standard != ERC721 && c.Standard != ERC1155 {
+			return nil, fmt.Errorf("collection %s: unknown standard %q (erc721|erc1155)", c.Address, c.Standard)
+		}
+		if c.Standard == ERC1155 && len(c.TokenIDs) == 0 {
+			return nil, fmt.Errorf("collection %s: erc1155 needs token_ids", c.Address)
+		}
+		if _, err := c.IDs(); err != nil {
+			return nil, fmt.Errorf("collection %s: %w", c.Address, err)
+		}
+		if c.Name == "" {
+			c.Name = common.HexToAddress(c.Address).Hex()
+		}
+	}
+	return out, nil
+}
+
+// IDs parses the configured token ids (decimal or 0x-hex).
+func (c Collection) IDs() ([]*big.Int, error) {
+	ids := make([]*big.Int, 0, len(c.TokenIDs))
+	for _, s := range c.TokenIDs {
+		id, ok := new(big.Int).SetString(s, 0)
+		if !ok || id.Sign() < 0 {
+			return nil, errors.New("invalid token id " + s)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// LoadFloors reads a JSON object of floor prices keyed by collection address:
+//
+//	{ "0x57f1...eA85": { "price": "0.002", "currency": "ETH" } }
+func LoadFloors(path string) (map[common.Address]Floor, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]Floor
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	out := make(map[common.Address]Floor, len(raw))
+	for k, f := range raw {
+		if !common.IsHexAddress(k) {
+			return nil, errors.New("floor key is not a collection address: " + k)
+		}
+		f.Currency = strings.ToUpper(f.Currency)
+		if f.Currency == "" {
+			f.Currency = ETH
+		}
+		if f.Currency != ETH && f.Currency != USD {
+			return nil, fmt.Errorf("floor %s: unknown currency %q (ETH|USD)", k, f.Currency)
+		}
+		r, ok := new(big.Rat).SetString(f.Price)
+		if !ok || r.Sign() < 0 {
+			return nil, fmt.Errorf("floor %s: invalid price %q", k, f.Price)
+		}
+		out[common.HexToAddress(k)] = f
+	}
+	return out, nil
+}
@@ -0,0 +1,90 @@
+package nft
+
+import (
+	"embed"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Встраиваем ABI ERC-721 (+ Enumerable) и ERC-1155 balanceOfBatch.
+//
+//go:embed abi/nft.json
+var nftFS embed.FS
+
+// Codec encodes/decodes ERC-721 and ERC-1155 reads; one instance serves every collection.
+type Codec struct {
+	abi abi.ABI
+}
+
+func NewCodec() (*Codec, error) {
+	abiBytes, err := nftFS.ReadFile("abi/nft.json")
+	if err != nil {
+		return nil, err
+	}
+	a, err := abi.JSON(strings.NewReader(string(abiBytes)))
+	if err != nil {
+		return nil, err
+	}
+	return &Codec{abi: a}, nil
+}
+
+// PackBalanceOf encodes ERC-721 balanceOf(owner).
+func (c *Codec) PackBalanceOf(owner common.Address) ([]byte, error) {
+	return c.abi.Pack("balanceOf", owner)
+}
+
+func (c *Codec) PackOwnerOf(id *big.Int) ([]byte, error) { return c.abi.Pack("ownerOf", id) }
+
+func (c *Codec) PackTokenOfOwnerByIndex(owner common.Address, i int64) ([]byte, error) {
+	return c.abi.Pack("tokenOfOwnerByIndex", owner, big.NewInt(i))
+}
+
+// PackBalanceOfBatch encodes ERC-1155 balanceOfBatch for one owner and many ids.
+func (c *Codec) PackBalanceOfBatch(owner common.Address, ids []*big.Int) ([]byte, error) {
+	owners := make([]common.Address, len(ids))
+	for i := range owners {
+		owners[i] = owner
+	}
+	return c.abi.Pack("balanceOfBatch", owners, ids)
+}
+
+// UnpackUint decodes the result of balanceOf or tokenOfOwnerByIndex.
+func (c *Codec) UnpackUint(method string, out []byte) (*big.Int, error) {
+	res, err := c.abi.Unpack(method, out)
+	if err != nil || len(res) != 1 {
+		return nil, errors.New("unpack " + method)
+	}
+	v, ok := res[0].(*big.Int)
+	if !ok {
+		return nil, errors.New("unexpected " + method + " type")
+	}
+	return v, nil
+}
+
+func (c *Codec) UnpackOwnerOf(out []byte) (common.Address, error) {
+	res, err := c.abi.Unpack("ownerOf", out)
+	if err != nil || len(res) != 1 {
+		return common.Address{}, errors.New("unpack ownerOf")
+	}
+	addr, ok := res[0].(common.Address)
+	if !ok {
+		return common.Address{}, errors.New("unexpected ownerOf type")
+	}
+	return addr, nil
+}
+
+func (c *Codec) UnpackBalanceOfBatch(out []byte) ([]*big.Int, error) {
+	res, err := c.abi.Unpack("balanceOfBatch", out)
+	if err != nil || len(res) != 1 {
+		return nil, errors.New("unpack balanceOfBatch")
+	}
+	v, ok := res[0].([]*big.Int)
+	if !ok {
+		return nil, errors.New("unexpected balanceOfBatch type")
+	}
+	return v, nil
+}
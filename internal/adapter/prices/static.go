@@ -0,0 +1,29 @@
+package prices
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+)
+
+// Load reads a JSON object of fixed USD prices keyed by token address or symbol:
+//
+//	{ "0x6B17...1d0F": "1.00", "WBTC": "64000" }
+func Load(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]string
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	for k, v := range out {
+		r, ok := new(big.Rat).SetString(v)
+		if !ok || r.Sign() <= 0 {
+			return nil, errors.New("invalid price for " + k + ": " + v)
+		}
+	}
+	return out, nil
+}
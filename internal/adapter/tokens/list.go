@@ -1,24 +1,90 @@
 package tokens
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"os"
+	"strings"
 )
 
 type Token struct {
 	Address  string `json:"address"`
 	Symbol   string `json:"symbol"`
 	Decimals int    `json:"decimals,omitempty"`
+	Feed     string `json:"feed,omitempty"` // Chainlink AggregatorV3 proxy quoting the token in USD
+
+	Heartbeat string `json:"heartbeat,omitempty"` // max price age, e.g. "1h"; overrides --heartbeat
+	MinPrice  string `json:"min_price,omitempty"` // optional sanity bounds on the USD price
+	MaxPrice  string `json:"max_price,omitempty"`
+
+	UniswapV3Pool string `json:"uniswap_v3_pool,omitempty"` // pool against WETH or a priced token, for TWAP pricing
+	TWAPWindow    string `json:"twap_window,omitempty"`     // e.g. "30m"; overrides --twap-window
+
+	Rate       string `json:"rate,omitempty"`       // wrapper rate: erc4626|stEthPerToken|getExchangeRate|exchangeRate|1:1
+	Underlying string `json:"underlying,omitempty"` // wrapped asset address or "eth://native"; erc4626 defaults to asset()
+
+	LP string `json:"lp,omitempty"` // "uniswap-v2": the token is a pair, valued by its share of the reserves
+
+	Discovered bool `json:"-"` // found in Transfer logs; only valued when the balance is non-zero
 }
 
-func Load(path string) ([]Token, error) {
-	if path == "" {
+// Load reads token sources in order and merges them by address: a later entry overrides
+// the non-empty fields of an earlier one, so a small user file can patch a public list.
+// A source is a file path or "builtin:<name>" and holds either a bare JSON array of Tokens
+// or a token list in the tokenlists.org format, of which only entries of chainID are kept.
+// Without sources Load returns DefaultList.
+func Load(chainID uint64, sources ...string) ([]Token, error) {
+	if len(sources) == 0 {
 		return DefaultList, nil
 	}
-	b, err := os.ReadFile(path)
+	var (
+		out   []Token
+		index = make(map[string]int)
+	)
+	for _, src := range sources {
+		toks, err := loadOne(chainID, src)
+		if err != nil {
+			return nil, fmt.Errorf("tokens %s: %w", src, err)
+		}
+		for _, t := range toks {
+			key := strings.ToLower(t.Address)
+			if i, ok := index[key]; ok {
+				out[i] = merge(out[i], t)
+				continue
+			}
+			index[key] = len(out)
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func loadOne(chainID uint64, src string) ([]Token, error) {
+	var (
+		b   []byte
+		err error
+	)
+	if name, ok := strings.CutPrefix(src, builtinPrefix); ok {
+		b, err = readBuiltin(name)
+	} else {
+		b, err = os.ReadFile(src)
+	}
 	if err != nil {
 		return nil, err
 	}
+
+	if trimmed := bytes.TrimSpace(b); len(trimmed) > 0 && trimmed[0] == '{' {
+		var list TokenList
+		if err := json.Unmarshal(b, &list); err != nil {
+			return nil, err
+		}
+		if err := list.Validate(); err != nil {
+			return nil, err
+		}
+		return list.ForChain(chainID), nil
+	}
+
 	var out []Token
 	if err := json.Unmarshal(b, &out); err != nil {
 		return nil, err
@@ -26,6 +92,29 @@ func Load(path string) ([]Token, error) {
 	return out, nil
 }
 
+// merge overlays the set fields of o on t.
+func merge(t, o Token) Token {
+	set := func(dst *string, v string) {
+		if v != "" {
+			*dst = v
+		}
+	}
+	set(&t.Symbol, o.Symbol)
+	set(&t.Feed, o.Feed)
+	set(&t.Heartbeat, o.Heartbeat)
+	set(&t.MinPrice, o.MinPrice)
+	set(&t.MaxPrice, o.MaxPrice)
+	set(&t.UniswapV3Pool, o.UniswapV3Pool)
+	set(&t.TWAPWindow, o.TWAPWindow)
+	set(&t.Rate, o.Rate)
+	set(&t.Underlying, o.Underlying)
+	set(&t.LP, o.LP)
+	if o.Decimals != 0 {
+		t.Decimals = o.Decimals
+	}
+	return t
+}
+
 var DefaultList = []Token{
 	{Address: "eth://native", Symbol: "ETH", Decimals: 18},
 }
@@ -0,0 +1,158 @@
+package tokens
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Встроенные списки в формате tokenlists.org, доступны как "builtin:<name>".
+//
+//go:embed lists/*.json
+var listsFS embed.FS
+
+const builtinPrefix = "builtin:"
+
+// TokenList is a list in the Uniswap Token Lists format (https://tokenlists.org).
+type TokenList struct {
+	Name      string          `json:"name"`
+	Timestamp string          `json:"timestamp"`
+	Version   Version         `json:"version"`
+	LogoURI   string          `json:"logoURI,omitempty"`
+	Keywords  []string        `json:"keywords,omitempty"`
+	Tags      json.RawMessage `json:"tags,omitempty"`
+	Tokens    []ListToken     `json:"tokens"`
+}
+
+type Version struct {
+	Major int `json:"major"`
+	Minor int `json:"minor"`
+	Patch int `json:"patch"`
+}
+
+// ListToken is one entry of a TokenList.
+type ListToken struct {
+	ChainID    uint64                     `json:"chainId"`
+	Address    string                     `json:"address"`
+	Name       string                     `json:"name"`
+	Symbol     string                     `json:"symbol"`
+	Decimals   int                        `json:"decimals"`
+	LogoURI    string                     `json:"logoURI,omitempty"`
+	Tags       []string                   `json:"tags,omitempty"`
+	Extensions map[string]json.RawMessage `json:"extensions,omitempty"`
+}
+
+// limits and patterns of the token list JSON schema
+const (
+	maxListTokens     = 10000
+	maxListNameLen    = 30
+	maxTokenNameLen   = 60
+	maxTokenTags      = 10
+	maxTokenExtension = 10
+)
+
+var (
+	listNameRe = regexp.MustCompile(`^[\w ]+$`)
+	symbolRe   = regexp.MustCompile(`^[a-zA-Z0-9+\-%/$.]{1,20}$`)
+)
+
+// Validate checks the list against the token list schema and reports the first problems found.
+func (l TokenList) Validate() error {
+	var errs []error
+	if n := utf8.RuneCountInString(l.Name); n == 0 || n > maxListNameLen || !listNameRe.MatchString(l.Name) {
+		errs = append(errs, fmt.Errorf("name %q: 1-%d word characters or spaces", l.Name, maxListNameLen))
+	}
+	if l.Timestamp == "" {
+		errs = append(errs, errors.New("timestamp is required"))
+	}
+	if l.Version.Major < 0 || l.Version.Minor < 0 || l.Version.Patch < 0 {
+		errs = append(errs, errors.New("version parts must be non-negative"))
+	}
+	if l.LogoURI != "" && !validURI(l.LogoURI) {
+		errs = append(errs, fmt.Errorf("logoURI %q is not a URI", l.LogoURI))
+	}
+	if len(l.Tokens) == 0 || len(l.Tokens) > maxListTokens {
+		errs = append(errs, fmt.Errorf("tokens: 1-%d entries required", maxListTokens))
+	}
+
+	seen := make(map[string]bool, len(l.Tokens))
+	for i, t := range l.Tokens {
+		if err := t.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("tokens[%d]: %w", i, err))
+			continue
+		}
+		key := fmt.Sprintf("%d:%s", t.ChainID, strings.ToLower(t.Address))
+		if seen[key] {
+			errs = append(errs, fmt.Errorf("tokens[%d]: duplicate %s on chain %d", i, t.Address, t.ChainID))
+		}
+		seen[key] = true
+		if len(errs) >= 10 {
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t ListToken) validate() error {
+	switch {
+	case t.ChainID == 0:
+		return errors.New("chainId must be positive")
+	case !common.IsHexAddress(t.Address) || !strings.HasPrefix(t.Address, "0x"):
+		return fmt.Errorf("address %q is not a 0x-prefixed hex address", t.Address)
+	case utf8.RuneCountInString(t.Name) == 0 || utf8.RuneCountInString(t.Name) > maxTokenNameLen:
+		return fmt.Errorf("name: 1-%d characters", maxTokenNameLen)
+	case !symbolRe.MatchString(t.Symbol):
+		return fmt.Errorf("symbol %q is invalid", t.Symbol)
+	case t.Decimals < 0 || t.Decimals > 255:
+		return fmt.Errorf("decimals %d out of range 0-255", t.Decimals)
+	case t.LogoURI != "" && !validURI(t.LogoURI):
+		return fmt.Errorf("logoURI %q is not a URI", t.LogoURI)
+	case len(t.Tags) > maxTokenTags:
+		return fmt.Errorf("at most %d tags", maxTokenTags)
+	case len(t.Extensions) > maxTokenExtension:
+		return fmt.Errorf("at most %d extensions", maxTokenExtension)
+	}
+	return nil
+}
+
+func validURI(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != ""
+}
+
+// ForChain converts the entries of chainID into Tokens; chainID 0 keeps every entry.
+func (l TokenList) ForChain(chainID uint64) []Token {
+	var out []Token
+	for _, t := range l.Tokens {
+		if chainID != 0 && t.ChainID != chainID {
+			continue
+		}
+		out = append(out, Token{Address: t.Address, Symbol: t.Symbol, Decimals: t.Decimals})
+	}
+	return out
+}
+
+// Builtin returns the names of the embedded lists.
+func Builtin() []string {
+	entries, _ := listsFS.ReadDir("lists")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".json"))
+	}
+	return names
+}
+
+func readBuiltin(name string) ([]byte, error) {
+	b, err := listsFS.ReadFile("lists/" + name + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("unknown builtin token list %q (have %s)", name, strings.Join(Builtin(), ", "))
+	}
+	return b, nil
+}
@@ -3,13 +3,10 @@ package cli
 import (
 	"context"
 	"fmt"
-	"math/big"
 	"os"
 	"time"
 
-	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/chainlink"
-	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/eth"
-	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/tokens"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/transport"
 	"github.com/dayanaadylkhanova/eth2usd/internal/app"
 	"github.com/dayanaadylkhanova/eth2usd/internal/service"
 	"github.com/dayanaadylkhanova/eth2usd/pkg/logger"
@@ -22,85 +19,62 @@ type CLIRunner struct {
 func NewCLIRunner(log *logger.Logger) *CLIRunner { return &CLIRunner{log: log} }
 
 func (r *CLIRunner) Run(ctx context.Context, cfg app.RunConfig) error {
-	// deps
-	ethc, err := eth.NewClient(ctx, cfg.RPCURL)
+	// failure policy
+	policy, err := transport.Policy(cfg)
 	if err != nil {
 		return err
 	}
-	defer ethc.Close()
 
-	feed, err := chainlink.NewFeedRegistry(cfg.ChainlinkRegistry)
+	// output format, checked before any RPC work
+	if _, err := service.LookupFormat(cfg.Format); err != nil {
+		return err
+	}
+	formatOpts, err := transport.FormatOptions(cfg)
 	if err != nil {
 		return err
 	}
 
-	// tokens
-	toks, err := tokens.Load(cfg.TokensFile)
+	// accounts
+	accs, err := transport.Accounts(cfg.Accounts, cfg.AccountsFile)
 	if err != nil {
 		return err
 	}
-	if len(toks) == 0 {
-		return fmt.Errorf("no tokens to process")
-	}
-
-	valuator := service.NewValuator(r.log, ethc, feed)
 
-	// evaluate
-	res := service.ValuationResult{Rows: make([]service.ValuationRow, 0, len(toks))}
-	for _, t := range toks {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
+	// deps
+	deps, err := transport.Setup(ctx, r.log, cfg)
+	if err != nil {
+		return err
+	}
+	defer deps.Close()
 
-		row, err := valuator.ValueOne(ctx, cfg.Account, t)
-		if err != nil {
-			r.log.Errorf("token %s: %v", t.Symbol, err)
-			// keep going, add an error row
-			res.Rows = append(res.Rows, service.ValuationRow{
-				Symbol: t.Symbol,
-				Amount: "0",
-				USD:    "0",
-				Source: "error",
-				Err:    err.Error(),
-			})
-			continue
-		}
-		res.Rows = append(res.Rows, row)
+	if cfg.Watch > 0 {
+		return r.watch(ctx, deps, accs, cfg, policy, formatOpts)
 	}
 
-	// totals
-	var totalUSD = new(big.Rat)
-	for _, row := range res.Rows {
-		if row.Err != "" {
-			continue
-		}
-		v, ok := new(big.Rat).SetString(row.USD)
-		if ok {
-			totalUSD.Add(totalUSD, v)
-		}
+	// evaluate, pinning every read to one block
+	res, err := deps.Value(ctx, accs, deps.Tokens, cfg.Block, cfg.At)
+	if err != nil {
+		return err
 	}
-	res.TotalUSD = service.FormatRat(totalUSD, 2)
+	r.log.Infof("valued at block %d (%s)", res.Block, res.BlockTime.UTC().Format(time.RFC3339))
 
-	// output
-	var out string
-	switch cfg.Format {
-	case "json":
-		out, err = service.FormatJSON(res)
-	default:
-		out, err = service.FormatText(res)
+	if err := r.write(cfg, formatOpts, res); err != nil {
+		return err
 	}
+
+	// the report is written either way; the policy only decides the exit status
+	return policy.Check(res)
+}
+
+// write renders the full report to --out, or to stdout.
+func (r *CLIRunner) write(cfg app.RunConfig, opts service.FormatOptions, res service.ValuationResult) error {
+	out, err := transport.Render(cfg.Format, opts, res)
 	if err != nil {
 		return err
 	}
-
 	if cfg.Output == "" {
 		fmt.Println(out)
 		return nil
 	}
 	return os.WriteFile(cfg.Output, []byte(out), 0o644)
 }
-
-// _ = time to avoid unused import on some toolchains
-var _ = time.Second
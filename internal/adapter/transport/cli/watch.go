@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/transport"
+	"github.com/dayanaadylkhanova/eth2usd/internal/app"
+	"github.com/dayanaadylkhanova/eth2usd/internal/service"
+)
+
+// watch re-values the accounts on the same connection until ctx is cancelled: on every new
+// block when the RPC is a WebSocket (at most once per cfg.Watch), otherwise every cfg.Watch.
+// The first report is printed in full, then only what changed; --out always holds the latest report.
+func (r *CLIRunner) watch(ctx context.Context, deps *transport.Deps, accs []string, cfg app.RunConfig,
+	policy service.FailurePolicy, opts service.FormatOptions) error {
+	if cfg.Block != "" || !cfg.At.IsZero() {
+		return errors.New("--watch follows the latest block; drop --block/--at")
+	}
+	color := isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == ""
+
+	var prev *service.ValuationResult
+	run := func(block string) {
+		runCtx := ctx
+		if cfg.Timeout > 0 {
+			var cancel context.CancelFunc
+			runCtx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+			defer cancel()
+		}
+		res, err := deps.Value(runCtx, accs, deps.Tokens, block, time.Time{})
+		if err != nil {
+			if ctx.Err() == nil {
+				r.log.Errorf("valuation: %v", err)
+			}
+			return
+		}
+
+		if prev == nil || cfg.Output != "" {
+			if err := r.write(cfg, opts, res); err != nil {
+				r.log.Errorf("output: %v", err)
+			}
+		}
+		if prev != nil {
+			currency := "USD"
+			if res.Quote != "" {
+				currency = res.Quote
+			}
+			if d := service.DiffResults(*prev, res); !d.Empty() {
+				fmt.Print(service.FormatDiff(d, currency, color))
+			}
+		}
+		// a failing row doesn't end the watch, it is reported and checked again next time
+		if err := policy.Check(res); err != nil {
+			r.log.Errorf("%v", err)
+		}
+		prev = &res
+	}
+
+	heads := r.subscribe(ctx, deps, cfg)
+	ticker := time.NewTicker(cfg.Watch)
+	defer ticker.Stop()
+	if heads != nil {
+		ticker.Stop() // new blocks drive the runs
+	}
+
+	run("")
+	last := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case h, ok := <-heads:
+			if !ok {
+				r.log.Errorf("newHeads subscription ended, polling every %s", cfg.Watch)
+				heads = nil
+				ticker.Reset(cfg.Watch)
+				continue
+			}
+			if time.Since(last) < cfg.Watch {
+				continue
+			}
+			last = time.Now()
+			run(h.Number.String())
+		case <-ticker.C:
+			run("")
+		}
+	}
+}
+
+// subscribe streams new heads when the RPC is a WebSocket; nil means polling.
+// The returned channel is closed when the subscription fails.
+func (r *CLIRunner) subscribe(ctx context.Context, deps *transport.Deps, cfg app.RunConfig) <-chan *types.Header {
+	if !strings.HasPrefix(cfg.RPCURL, "ws://") && !strings.HasPrefix(cfg.RPCURL, "wss://") {
+		return nil
+	}
+	in := make(chan *types.Header, 16)
+	sub, err := deps.Eth.SubscribeNewHeads(ctx, in)
+	if err != nil {
+		r.log.Errorf("newHeads subscription: %v; polling every %s", err, cfg.Watch)
+		return nil
+	}
+	r.log.Infof("watching new blocks")
+
+	out := make(chan *types.Header)
+	go func() {
+		defer close(out)
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err := <-sub.Err():
+				if err != nil {
+					r.log.Errorf("newHeads subscription: %v", err)
+				}
+				return
+			case h := <-in:
+				// a slow valuation skips the heads it missed
+				select {
+				case out <- h:
+				case <-ctx.Done():
+					return
+				default:
+				}
+			}
+		}
+	}()
+	return out
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
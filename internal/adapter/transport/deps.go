@@ -0,0 +1,323 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/chainlink"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/eth"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/network"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/nft"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/tokens"
+	"github.com/dayanaadylkhanova/eth2usd/internal/app"
+	"github.com/dayanaadylkhanova/eth2usd/internal/service"
+	"github.com/dayanaadylkhanova/eth2usd/pkg/logger"
+)
+
+// Deps is the set of long-lived dependencies shared by every transport:
+// one RPC connection, the configured token list and a valuator that is not yet pinned to a block.
+type Deps struct {
+	Eth      *eth.Client
+	Tokens   []tokens.Token
+	Valuator *service.Valuator
+
+	scanner      *eth.TransferScanner // nil unless token discovery is on
+	discoverFrom uint64
+
+	mu         sync.Mutex // guards discovered: the HTTP server values concurrently
+	discovered map[common.Address]*discovery
+}
+
+// discovery is what an account's Transfer scan has found so far.
+type discovery struct {
+	tokens  []common.Address
+	scanned uint64 // last block scanned, inclusive
+}
+
+// Setup dials the RPC and wires the valuator from cfg. Close must be called when done.
+func Setup(ctx context.Context, log *logger.Logger, cfg app.RunConfig) (*Deps, error) {
+	ethc, err := eth.NewClient(ctx, cfg.RPCURL)
+	if err != nil {
+		return nil, service.NewFailure(service.FailRPC, fmt.Errorf("connect: %w", err))
+	}
+
+	log.Infof("connected to %s", network.Describe(ethc.ChainID))
+
+	d, err := wire(log, ethc, cfg)
+	if err != nil {
+		ethc.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// wire builds everything on top of an established connection.
+func wire(log *logger.Logger, ethc *eth.Client, cfg app.RunConfig) (*Deps, error) {
+	preset, err := applyPreset(ethc.ChainID, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(preset.Tokens) == 0 && len(cfg.TokensFiles) == 0 && !cfg.Discover {
+		// valuing just the native balance would silently miss every ERC-20 the account holds,
+		// on a preset without a list as much as on a chain without a preset
+		return nil, fmt.Errorf("no default token list for %s: pass --tokens-file (a token file or builtin:<name>) or --discover",
+			network.Describe(ethc.ChainID))
+	}
+	toks, err := loadTokens(ethc.ChainID, preset, cfg.TokensFiles)
+	if err != nil {
+		return nil, err
+	}
+	if len(toks) == 0 && !cfg.Discover {
+		return nil, errors.New("no tokens to process")
+	}
+
+	sources, err := priceSources(log, cfg, preset, toks)
+	if err != nil {
+		return nil, err
+	}
+
+	rounding := service.RoundHalfEven
+	if cfg.Rounding != "" {
+		rounding, err = service.ParseRoundingMode(cfg.Rounding)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	valuator := service.NewValuator(log, ethc, sources).
+		WithConcurrency(cfg.Concurrency).
+		WithRounding(rounding).
+		WithHeartbeat(cfg.Heartbeat)
+	if preset.ChainID != 0 {
+		valuator = valuator.WithNative(preset.Native)
+	}
+	readers, err := positionReaders(cfg, ethc.ChainID, preset, toks)
+	if err != nil {
+		return nil, err
+	}
+	if len(readers) > 0 {
+		valuator = valuator.WithPositions(readers...)
+	}
+	if slices.Contains(cfg.Positions, "uniswap-v2") {
+		// pairs are valued through their reserves, not as fungible tokens
+		toks = slices.DeleteFunc(slices.Clone(toks), func(t tokens.Token) bool { return t.LP == "uniswap-v2" })
+	}
+	if cfg.Multicall {
+		addr := cfg.MulticallAddress
+		if addr == "" {
+			addr = eth.Multicall3Address
+		}
+		mc, err := eth.NewMulticall(ethc, addr)
+		if err != nil {
+			return nil, err
+		}
+		valuator = valuator.WithMulticall(mc)
+	}
+
+	if cfg.Quote != "" && cfg.Quote != chainlink.USD {
+		qt, err := quoteToken(cfg.Quote, cfg.QuoteFeed)
+		if err != nil {
+			return nil, err
+		}
+		valuator = valuator.WithQuote(cfg.Quote, qt)
+	}
+
+	if cfg.NFTCollections != "" {
+		collections, err := nft.LoadCollections(cfg.NFTCollections)
+		if err != nil {
+			return nil, fmt.Errorf("nft collections: %w", err)
+		}
+		var floors map[common.Address]nft.Floor
+		if cfg.NFTFloors != "" {
+			if floors, err = nft.LoadFloors(cfg.NFTFloors); err != nil {
+				return nil, fmt.Errorf("nft floors: %w", err)
+			}
+		}
+		codec, err := nft.NewCodec()
+		if err != nil {
+			return nil, err
+		}
+		valuator = valuator.WithNFTs(codec, collections, floors, ethToken(preset, toks))
+	} else if cfg.NFTFloors != "" {
+		return nil, errors.New("--nft-floors needs --nft-collections")
+	}
+
+	d := &Deps{Eth: ethc, Tokens: toks, Valuator: valuator}
+	if cfg.Discover {
+		if cfg.DiscoverFrom == 0 {
+			// a scan from genesis takes thousands of requests and most providers cap it anyway
+			return nil, errors.New("--discover needs --discover-from (first block to scan, e.g. when the accounts were created)")
+		}
+		d.scanner = eth.NewTransferScanner(ethc, cfg.DiscoverChunk)
+		d.discoverFrom = cfg.DiscoverFrom
+	}
+	return d, nil
+}
+
+// applyPreset checks cfg against the connected chain and fills the registry, WETH and
+// --quote ETH feed from the chain's preset when they are not set explicitly.
+func applyPreset(chainID uint64, cfg *app.RunConfig) (network.Preset, error) {
+	preset, known := network.ByChainID(chainID)
+	if cfg.Network != "" {
+		want, ok := network.ByName(cfg.Network)
+		if !ok {
+			return network.Preset{}, fmt.Errorf("unknown network %q (%s)", cfg.Network, strings.Join(network.Names(), "|"))
+		}
+		if want.ChainID != chainID {
+			return network.Preset{}, fmt.Errorf("--network %s expects chain %d, the RPC is on %s", want.Name, want.ChainID, network.Describe(chainID))
+		}
+	}
+	if cfg.ChainlinkRegistry != "" && known && preset.Registry == "" {
+		return network.Preset{}, fmt.Errorf("--chainlink-registry: the Feed Registry is only deployed on mainnet, the RPC is on %s; use per-token \"feed\" aggregators", network.Describe(chainID))
+	}
+
+	if cfg.ChainlinkRegistry == "" {
+		cfg.ChainlinkRegistry = preset.Registry
+	}
+	if cfg.WETH == "" {
+		cfg.WETH = preset.WETH
+	}
+	if cfg.Quote == chainlink.ETH && cfg.QuoteFeed == "" && preset.Registry == "" {
+		cfg.QuoteFeed = preset.ETHFeed
+	}
+	return preset, nil
+}
+
+func (d *Deps) Close() { d.Eth.Close() }
+
+// Pin resolves the requested block (at wins over block when set) and returns
+// a valuator that performs every read at it. A bad or unknown block or time is a *service.ConfigError,
+// a failed lookup a *service.FailureError of class rpc.
+func (d *Deps) Pin(ctx context.Context, block string, at time.Time) (*service.Valuator, *types.Header, error) {
+	var (
+		header *types.Header
+		err    error
+	)
+	if !at.IsZero() {
+		header, err = d.Eth.BlockAtTime(ctx, at)
+	} else {
+		header, err = d.Eth.ResolveBlock(ctx, block)
+	}
+	switch {
+	case err == nil:
+	case errors.Is(err, eth.ErrInvalidBlockSpec), errors.Is(err, eth.ErrBeforeGenesis):
+		return nil, nil, &service.ConfigError{Err: err}
+	case errors.Is(err, ethereum.NotFound):
+		// unknown block hash or a number past the head
+		return nil, nil, &service.ConfigError{Err: fmt.Errorf("block %q: %w", block, err)}
+	case ctx.Err() != nil:
+		return nil, nil, err
+	default:
+		return nil, nil, service.NewFailure(service.FailRPC, fmt.Errorf("pin block: %w", err))
+	}
+	return d.Valuator.AtBlock(header.Number, BlockTime(header)), header, nil
+}
+
+// Value pins the block and values accounts x toks, stamping the block into the result.
+func (d *Deps) Value(ctx context.Context, accs []string, toks []tokens.Token, block string, at time.Time) (service.ValuationResult, error) {
+	valuator, header, err := d.Pin(ctx, block, at)
+	if err != nil {
+		return service.ValuationResult{}, err
+	}
+	if d.scanner != nil {
+		toks, err = d.discover(ctx, accs, toks, header.Number.Uint64())
+		if err != nil {
+			return service.ValuationResult{}, err
+		}
+	}
+	res, err := valuator.ValueAccounts(ctx, accs, toks)
+	if err != nil {
+		return service.ValuationResult{}, err
+	}
+	res.Block = header.Number.Uint64()
+	res.BlockTime = BlockTime(header)
+	return res, nil
+}
+
+// discover appends the tokens accs received up to block that toks doesn't list yet.
+// Results are cached per account, so repeated valuations (watch, exporter, HTTP)
+// only scan the blocks past the last scan. A block below the cached height reuses
+// the cached set: tokens received later are simply valued at zero and dropped.
+func (d *Deps) discover(ctx context.Context, accs []string, toks []tokens.Token, block uint64) ([]tokens.Token, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.discovered == nil {
+		d.discovered = make(map[common.Address]*discovery)
+	}
+
+	// accounts scanned up to the same height share one log query
+	pending := make(map[uint64][]common.Address)
+	var addrs []common.Address
+	for _, a := range accs {
+		addr := common.HexToAddress(a)
+		addrs = append(addrs, addr)
+		from := d.discoverFrom
+		if c := d.discovered[addr]; c != nil {
+			if c.scanned >= block {
+				continue
+			}
+			from = c.scanned + 1
+		}
+		if !slices.Contains(pending[from], addr) {
+			pending[from] = append(pending[from], addr)
+		}
+	}
+	for from, group := range pending {
+		got, err := d.scanner.Tokens(ctx, group, from, block)
+		if err != nil {
+			return nil, fmt.Errorf("token discovery: %w", err)
+		}
+		for _, addr := range group {
+			c := d.discovered[addr]
+			if c == nil {
+				c = &discovery{}
+				d.discovered[addr] = c
+			}
+			for _, t := range got[addr] {
+				if !slices.Contains(c.tokens, t) {
+					c.tokens = append(c.tokens, t)
+				}
+			}
+			c.scanned = block
+		}
+	}
+
+	var found []common.Address
+	for _, addr := range addrs {
+		for _, t := range d.discovered[addr].tokens {
+			if !slices.Contains(found, t) {
+				found = append(found, t)
+			}
+		}
+	}
+
+	known := make(map[common.Address]bool, len(toks))
+	for _, t := range toks {
+		if common.IsHexAddress(t.Address) {
+			known[common.HexToAddress(t.Address)] = true
+		}
+	}
+	out := append([]tokens.Token{}, toks...)
+	for _, a := range found {
+		if !known[a] {
+			out = append(out, tokens.Token{Address: a.Hex(), Discovered: true})
+		}
+	}
+	if len(out) == 0 {
+		return nil, errors.New("no tokens to process")
+	}
+	return out, nil
+}
+
+// BlockTime converts a header timestamp to time.Time.
+func BlockTime(h *types.Header) time.Time { return time.Unix(int64(h.Time), 0) }
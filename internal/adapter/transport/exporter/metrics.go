@@ -0,0 +1,165 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/eth"
+	"github.com/dayanaadylkhanova/eth2usd/internal/service"
+)
+
+// snapshot is the state /metrics renders: the last valuation and how the runs went.
+type snapshot struct {
+	res      service.ValuationResult
+	ok       bool          // the last run succeeded
+	lastOK   time.Time     // end of the last successful run
+	duration time.Duration // of the last run
+	runs     uint64
+	failures uint64
+}
+
+// writeMetrics renders the Prometheus text exposition format (version 0.0.4).
+func writeMetrics(w io.Writer, s snapshot, rpc []eth.RPCStat) {
+	m := &expo{w: w}
+
+	m.family("eth2usd_up", "gauge", "Whether the last valuation run succeeded.")
+	m.sample("eth2usd_up", nil, boolValue(s.ok))
+	m.family("eth2usd_runs_total", "counter", "Valuation runs started.")
+	m.sample("eth2usd_runs_total", nil, strconv.FormatUint(s.runs, 10))
+	m.family("eth2usd_run_failures_total", "counter", "Valuation runs that failed as a whole.")
+	m.sample("eth2usd_run_failures_total", nil, strconv.FormatUint(s.failures, 10))
+	m.family("eth2usd_run_duration_seconds", "gauge", "Duration of the last valuation run.")
+	m.sample("eth2usd_run_duration_seconds", nil, seconds(s.duration))
+
+	if !s.lastOK.IsZero() {
+		res := s.res
+		m.family("eth2usd_last_success_timestamp_seconds", "gauge", "Unix time of the last successful run.")
+		m.sample("eth2usd_last_success_timestamp_seconds", nil, strconv.FormatInt(s.lastOK.Unix(), 10))
+		m.family("eth2usd_block", "gauge", "Block the last valuation was pinned to.")
+		m.sample("eth2usd_block", nil, strconv.FormatUint(res.Block, 10))
+
+		m.family("eth2usd_token_balance", "gauge", "Token amount held; borrows are negative.")
+		for _, row := range res.Rows {
+			m.sample("eth2usd_token_balance", rowLabels(row), row.Amount)
+		}
+		m.family("eth2usd_token_usd_value", "gauge", "USD value of the token amount.")
+		for _, row := range res.Rows {
+			if row.Err == "" {
+				m.sample("eth2usd_token_usd_value", rowLabels(row), row.USD)
+			}
+		}
+		m.family("eth2usd_row_errors", "gauge", "Rows that could not be valued cleanly, by failure class.")
+		for _, row := range res.Rows {
+			if row.ErrClass != "" {
+				m.sample("eth2usd_row_errors", append(rowLabels(row), "class", string(row.ErrClass)), "1")
+			}
+		}
+
+		// one price per token contract; rows of several accounts share it,
+		// and tokens sharing a symbol (clones, the "TKN" fallback) keep their own
+		m.family("eth2usd_price_usd", "gauge", "USD price used for the token.")
+		ages := make(map[string]time.Time)
+		seen := make(map[string]bool)
+		for _, row := range res.Rows {
+			if row.Price == "" || seen[row.Token] {
+				continue
+			}
+			seen[row.Token] = true
+			m.sample("eth2usd_price_usd", []string{"token", row.Token, "symbol", row.Symbol, "source", row.Source}, row.Price)
+			if !row.UpdatedAt.IsZero() {
+				ages[row.Token] = row.UpdatedAt
+			}
+		}
+		m.family("eth2usd_price_age_seconds", "gauge", "Age of the price at the valuation block.")
+		ref := res.BlockTime
+		if ref.IsZero() {
+			ref = s.lastOK
+		}
+		for _, row := range res.Rows {
+			if at, ok := ages[row.Token]; ok {
+				m.sample("eth2usd_price_age_seconds", []string{"token", row.Token, "symbol", row.Symbol}, seconds(ref.Sub(at)))
+				delete(ages, row.Token)
+			}
+		}
+
+		m.family("eth2usd_total_usd", "gauge", "USD total of the valued rows of an account.")
+		for _, a := range res.Accounts {
+			m.sample("eth2usd_total_usd", []string{"account", a.Account}, a.TotalUSD)
+		}
+		m.family("eth2usd_portfolio_usd", "gauge", "USD total of all accounts.")
+		m.sample("eth2usd_portfolio_usd", nil, res.TotalUSD)
+		if res.NFTTotalUSD != "" {
+			m.family("eth2usd_nft_total_usd", "gauge", "Floor value of the NFT holdings, apart from eth2usd_total_usd.")
+			m.sample("eth2usd_nft_total_usd", nil, res.NFTTotalUSD)
+		}
+	}
+
+	m.family("eth2usd_rpc_requests_total", "counter", "RPC requests by method.")
+	for _, st := range rpc {
+		m.sample("eth2usd_rpc_requests_total", []string{"method", st.Method}, strconv.FormatUint(st.Calls, 10))
+	}
+	m.family("eth2usd_rpc_errors_total", "counter", "RPC requests that failed, by method.")
+	for _, st := range rpc {
+		m.sample("eth2usd_rpc_errors_total", []string{"method", st.Method}, strconv.FormatUint(st.Errors, 10))
+	}
+	m.family("eth2usd_rpc_request_duration_seconds", "histogram", "RPC request latency by method.")
+	for _, st := range rpc {
+		for i, le := range eth.LatencyBuckets {
+			m.sample("eth2usd_rpc_request_duration_seconds_bucket",
+				[]string{"method", st.Method, "le", strconv.FormatFloat(le, 'g', -1, 64)}, strconv.FormatUint(st.Buckets[i], 10))
+		}
+		m.sample("eth2usd_rpc_request_duration_seconds_bucket", []string{"method", st.Method, "le", "+Inf"}, strconv.FormatUint(st.Calls, 10))
+		m.sample("eth2usd_rpc_request_duration_seconds_sum", []string{"method", st.Method}, strconv.FormatFloat(st.Sum, 'g', -1, 64))
+		m.sample("eth2usd_rpc_request_duration_seconds_count", []string{"method", st.Method}, strconv.FormatUint(st.Calls, 10))
+	}
+}
+
+// rowLabels identify a row by its token contract; the symbol is informational, it isn't unique.
+func rowLabels(row service.ValuationRow) []string {
+	return []string{"account", row.Account, "token", row.Token, "symbol", row.Symbol, "position", row.Position}
+}
+
+func boolValue(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func seconds(d time.Duration) string { return strconv.FormatFloat(d.Seconds(), 'f', 3, 64) }
+
+// expo writes metric families; samples with unparsable values are dropped.
+type expo struct {
+	w io.Writer
+}
+
+func (e *expo) family(name, kind, help string) {
+	fmt.Fprintf(e.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// sample writes one line; labels are name/value pairs.
+func (e *expo) sample(name string, labels []string, value string) {
+	if _, err := strconv.ParseFloat(value, 64); err != nil {
+		return
+	}
+	var b strings.Builder
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(labels[i] + `="` + escapeLabel(labels[i+1]) + `"`)
+		}
+		b.WriteByte('}')
+	}
+	fmt.Fprintf(e.w, "%s %s\n", b.String(), value)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
@@ -0,0 +1,130 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/transport"
+	"github.com/dayanaadylkhanova/eth2usd/internal/app"
+	"github.com/dayanaadylkhanova/eth2usd/pkg/logger"
+)
+
+const (
+	shutdownTimeout = 10 * time.Second
+	// DefaultInterval is the time between valuation runs when --interval is not set.
+	DefaultInterval = time.Minute
+)
+
+// ExporterRunner values the accounts on a schedule and serves the last result as Prometheus metrics.
+type ExporterRunner struct {
+	log *logger.Logger
+
+	mu   sync.Mutex
+	last snapshot
+}
+
+func NewExporterRunner(log *logger.Logger) *ExporterRunner { return &ExporterRunner{log: log} }
+
+func (r *ExporterRunner) Run(ctx context.Context, cfg app.RunConfig) error {
+	if cfg.Listen == "" {
+		return errors.New("listen address is required")
+	}
+	accs, err := transport.Accounts(cfg.Accounts, cfg.AccountsFile)
+	if err != nil {
+		return err
+	}
+
+	deps, err := transport.Setup(ctx, r.log, cfg)
+	if err != nil {
+		return err
+	}
+	defer deps.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, _ *http.Request) {
+		r.mu.Lock()
+		s := r.last
+		r.mu.Unlock()
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		writeMetrics(w, s, deps.Eth.Metrics().Snapshot())
+	})
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	srv := &http.Server{
+		Addr:              cfg.Listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		r.log.Infof("serving metrics on %s", cfg.Listen)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// first run right away, then on every tick; a slow run delays the next one
+	r.runOnce(ctx, deps, accs, cfg)
+loop:
+	for {
+		select {
+		case err := <-errCh:
+			return err
+		case <-ctx.Done():
+			break loop
+		case <-ticker.C:
+			r.runOnce(ctx, deps, accs, cfg)
+		}
+	}
+
+	r.log.Infof("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// runOnce values the accounts at the latest block and publishes the result; failures keep the previous one.
+func (r *ExporterRunner) runOnce(ctx context.Context, deps *transport.Deps, accs []string, cfg app.RunConfig) {
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	res, err := deps.Value(ctx, accs, deps.Tokens, "", time.Time{})
+	took := time.Since(start)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.last.runs++
+	r.last.duration = took
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			r.log.Errorf("valuation: %v", err)
+		}
+		r.last.ok = false
+		r.last.failures++
+		return
+	}
+	r.last.ok = true
+	r.last.res = res
+	r.last.lastOK = time.Now()
+	r.log.Infof("valued at block %d in %s: %s USD", res.Block, took.Round(time.Millisecond), res.TotalUSD)
+}
@@ -0,0 +1,253 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/chainlink"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/tokens"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/transport"
+	"github.com/dayanaadylkhanova/eth2usd/internal/app"
+	"github.com/dayanaadylkhanova/eth2usd/internal/service"
+	"github.com/dayanaadylkhanova/eth2usd/pkg/logger"
+)
+
+const shutdownTimeout = 10 * time.Second
+
+// ServerRunner serves valuations over HTTP until the run context is cancelled.
+type ServerRunner struct {
+	log *logger.Logger
+}
+
+func NewServerRunner(log *logger.Logger) *ServerRunner { return &ServerRunner{log: log} }
+
+func (r *ServerRunner) Run(ctx context.Context, cfg app.RunConfig) error {
+	if cfg.Listen == "" {
+		return errors.New("listen address is required")
+	}
+
+	formatOpts, err := transport.FormatOptions(cfg)
+	if err != nil {
+		return err
+	}
+
+	deps, err := transport.Setup(ctx, r.log, cfg)
+	if err != nil {
+		return err
+	}
+	defer deps.Close()
+
+	h := &handler{log: r.log, deps: deps, cfg: cfg, opts: formatOpts}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/valuation", h.valuation)
+	mux.HandleFunc("POST /v1/valuation", h.valuation)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	srv := &http.Server{
+		Addr:              cfg.Listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		r.log.Infof("listening on %s", cfg.Listen)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	r.log.Infof("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+type handler struct {
+	log  *logger.Logger
+	deps *transport.Deps
+	cfg  app.RunConfig
+	opts service.FormatOptions
+}
+
+// valuationRequest is the POST body; query parameters fill in whatever it leaves empty.
+type valuationRequest struct {
+	Accounts []string       `json:"accounts"`
+	Tokens   []tokens.Token `json:"tokens"`
+	Format   string         `json:"format"`
+	Block    string         `json:"block"`
+}
+
+func (h *handler) valuation(w http.ResponseWriter, r *http.Request) {
+	var req valuationRequest
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	q := r.URL.Query()
+	if len(req.Accounts) == 0 {
+		req.Accounts = splitList(q["account"])
+	}
+	if req.Format == "" {
+		req.Format = q.Get("format")
+	}
+	if req.Format == "" {
+		req.Format = "json"
+	}
+	if req.Block == "" {
+		req.Block = q.Get("block")
+	}
+	format, err := service.LookupFormat(req.Format)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	accs, err := transport.Accounts(req.Accounts, "")
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	toks := req.Tokens
+	if len(toks) == 0 {
+		toks, err = selectTokens(h.deps.Tokens, splitList(q["tokens"]))
+	} else {
+		err = checkTokens(toks)
+	}
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	ctx := r.Context()
+	if h.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.cfg.Timeout)
+		defer cancel()
+	}
+
+	res, err := h.deps.Value(ctx, accs, toks, req.Block, time.Time{})
+	if err != nil {
+		status := statusFor(err)
+		switch status {
+		case http.StatusBadRequest:
+		case statusClientClosed:
+			h.log.Infof("valuation: client went away: %v", err)
+		default:
+			h.log.Errorf("valuation: %v", err)
+		}
+		writeError(w, status, err)
+		return
+	}
+
+	out, err := format.Render(res, h.opts)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", format.ContentType)
+	_, _ = w.Write([]byte(out))
+}
+
+// selectTokens picks configured tokens by symbol or address; unknown addresses are
+// valued as ad-hoc ERC-20 tokens and "native" as the chain's native asset.
+// An empty selection means every configured token.
+func selectTokens(configured []tokens.Token, sel []string) ([]tokens.Token, error) {
+	if len(sel) == 0 {
+		return configured, nil
+	}
+	out := make([]tokens.Token, 0, len(sel))
+	for _, s := range sel {
+		t, ok := findToken(configured, s)
+		switch {
+		case ok:
+		case chainlink.IsNative(s):
+			t = tokens.Token{Address: chainlink.ETHPseudoAddress}
+		case common.IsHexAddress(s):
+			t = tokens.Token{Address: s}
+		default:
+			return nil, &service.ConfigError{Err: errors.New("unknown token: " + s)}
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+// checkTokens rejects token entries of a request that could never be read.
+func checkTokens(list []tokens.Token) error {
+	for _, t := range list {
+		if !chainlink.IsNative(t.Address) && !common.IsHexAddress(t.Address) {
+			return &service.ConfigError{Err: errors.New("token address is not hex: " + t.Address)}
+		}
+	}
+	return nil
+}
+
+func findToken(list []tokens.Token, s string) (tokens.Token, bool) {
+	for _, t := range list {
+		if strings.EqualFold(t.Symbol, s) || strings.EqualFold(t.Address, s) {
+			return t, true
+		}
+	}
+	return tokens.Token{}, false
+}
+
+// splitList accepts both repeated and comma-separated query values.
+func splitList(vals []string) []string {
+	var out []string
+	for _, v := range vals {
+		for _, p := range strings.Split(v, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				out = append(out, p)
+			}
+		}
+	}
+	return out
+}
+
+// statusClientClosed is nginx's non-standard 499: the client closed the request
+// before the response was ready. Nobody reads it, but access logs do.
+const statusClientClosed = 499
+
+// statusFor maps an error to its response status: 400 for a bad request, 499 when the
+// client went away, 504 when the upstream node timed out and 502 for any other upstream failure.
+func statusFor(err error) int {
+	var cfgErr *service.ConfigError
+	switch {
+	case errors.As(err, &cfgErr):
+		return http.StatusBadRequest
+	case errors.Is(err, context.Canceled):
+		return statusClientClosed
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,65 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/accounts"
+	"github.com/dayanaadylkhanova/eth2usd/internal/app"
+	"github.com/dayanaadylkhanova/eth2usd/internal/service"
+)
+
+// Accounts merges explicit accounts with an optional accounts file and normalizes the result.
+// Errors are *service.ConfigError.
+func Accounts(list []string, file string) ([]string, error) {
+	accs := list
+	if file != "" {
+		fromFile, err := accounts.Load(file)
+		if err != nil {
+			return nil, &service.ConfigError{Err: err}
+		}
+		accs = append(append([]string{}, accs...), fromFile...)
+	}
+	accs, err := accounts.Normalize(accs)
+	if err != nil {
+		return nil, &service.ConfigError{Err: err}
+	}
+	if len(accs) == 0 {
+		return nil, &service.ConfigError{Err: errors.New("no accounts to process")}
+	}
+	return accs, nil
+}
+
+// Policy builds the failure policy from --strict / --fail-on.
+func Policy(cfg app.RunConfig) (service.FailurePolicy, error) {
+	if cfg.Strict {
+		return service.StrictPolicy(), nil
+	}
+	return service.ParseFailOn(cfg.FailOn)
+}
+
+// Render formats the result with a registered format; unknown formats are an error.
+func Render(format string, opts service.FormatOptions, res service.ValuationResult) (string, error) {
+	f, err := service.LookupFormat(format)
+	if err != nil {
+		return "", err
+	}
+	return f.Render(res, opts)
+}
+
+// FormatOptions builds the formatter options from the CSV flags.
+func FormatOptions(cfg app.RunConfig) (service.FormatOptions, error) {
+	opts := service.FormatOptions{CSVNoHeader: cfg.CSVNoHeader}
+	switch d := cfg.CSVDelimiter; d {
+	case "", ",":
+	case "tab", `\t`:
+		opts.CSVDelimiter = '\t'
+	default:
+		runes := []rune(d)
+		if len(runes) != 1 || runes[0] == '"' || runes[0] == '\r' || runes[0] == '\n' {
+			return opts, fmt.Errorf("invalid --csv-delimiter %q: one character other than a quote or newline", d)
+		}
+		opts.CSVDelimiter = runes[0]
+	}
+	return opts, nil
+}
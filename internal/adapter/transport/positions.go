@@ -0,0 +1,99 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/aave"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/compound"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/network"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/tokens"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/uniswap"
+	"github.com/dayanaadylkhanova/eth2usd/internal/app"
+	"github.com/dayanaadylkhanova/eth2usd/internal/service"
+)
+
+// positionReaders builds the protocol readers listed in cfg.Positions.
+func positionReaders(cfg app.RunConfig, chainID uint64, preset network.Preset, toks []tokens.Token) ([]service.PositionReader, error) {
+	var readers []service.PositionReader
+	for _, name := range cfg.Positions {
+		switch name {
+		case "aave":
+			addr := cfg.AaveDataProvider
+			if addr == "" {
+				addr = preset.AaveDataProvider
+			}
+			if addr == "" {
+				return nil, fmt.Errorf("positions aave: no Aave V3 data provider for %s (pass --aave-data-provider)", network.Describe(chainID))
+			}
+			provider, err := aave.NewDataProvider(addr)
+			if err != nil {
+				return nil, err
+			}
+			readers = append(readers, service.NewAaveReader(provider, toks))
+		case "compound":
+			list := cfg.Comets
+			if len(list) == 0 {
+				list = preset.Comets
+			}
+			if len(list) == 0 {
+				return nil, fmt.Errorf("positions compound: no Compound V3 markets for %s (pass --comet)", network.Describe(chainID))
+			}
+			markets := make([]common.Address, 0, len(list))
+			for _, m := range list {
+				if !common.IsHexAddress(m) {
+					return nil, errors.New("comet address is not hex: " + m)
+				}
+				markets = append(markets, common.HexToAddress(m))
+			}
+			comet, err := compound.NewComet()
+			if err != nil {
+				return nil, err
+			}
+			readers = append(readers, service.NewCometReader(comet, markets, toks))
+		case "uniswap-v2":
+			pairs := lpTokens(toks, "uniswap-v2")
+			if len(pairs) == 0 {
+				return nil, errors.New(`positions uniswap-v2: no pairs (mark them with "lp": "uniswap-v2" in the tokens file)`)
+			}
+			pair, err := uniswap.NewPairV2()
+			if err != nil {
+				return nil, err
+			}
+			readers = append(readers, service.NewUniswapV2Reader(pair, pairs, toks))
+		case "uniswap-v3":
+			addr := cfg.PositionManager
+			if addr == "" {
+				addr = preset.PositionManager
+			}
+			if addr == "" {
+				return nil, fmt.Errorf("positions uniswap-v3: no position manager for %s (pass --uniswap-v3-position-manager)", network.Describe(chainID))
+			}
+			manager, err := uniswap.NewPositionManager(addr)
+			if err != nil {
+				return nil, err
+			}
+			pool, err := uniswap.NewPoolV3()
+			if err != nil {
+				return nil, err
+			}
+			readers = append(readers, service.NewUniswapV3Reader(manager, pool, toks))
+		default:
+			return nil, fmt.Errorf("unknown positions protocol %q (aave|compound|uniswap-v2|uniswap-v3)", name)
+		}
+	}
+	return readers, nil
+}
+
+// lpTokens picks the tokens marked as LP tokens of protocol.
+func lpTokens(toks []tokens.Token, protocol string) []tokens.Token {
+	var out []tokens.Token
+	for _, t := range toks {
+		if t.LP == protocol {
+			out = append(out, t)
+		}
+	}
+	return out
+}
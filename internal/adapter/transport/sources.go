@@ -0,0 +1,140 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/chainlink"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/network"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/prices"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/tokens"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/uniswap"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/wrapper"
+	"github.com/dayanaadylkhanova/eth2usd/internal/app"
+	"github.com/dayanaadylkhanova/eth2usd/internal/service"
+	"github.com/dayanaadylkhanova/eth2usd/pkg/logger"
+)
+
+// DefaultPriceSources is the fallback chain used when none is configured.
+var DefaultPriceSources = []string{"aggregator", "registry", "wrapper", "uniswap", "static"}
+
+// priceSources builds the ordered fallback chain from cfg.PriceSources.
+// Sources that are listed but not configured (no registry address, no prices file) are skipped.
+// The Uniswap source prices its quote leg with all the other sources of the chain, the
+// wrapper source prices underlying assets with them and the Uniswap source.
+func priceSources(log *logger.Logger, cfg app.RunConfig, preset network.Preset, toks []tokens.Token) ([]service.PriceSource, error) {
+	names := cfg.PriceSources
+	if len(names) == 0 {
+		names = DefaultPriceSources
+	}
+
+	var (
+		out       []service.PriceSource
+		composite = make(map[string]int) // position of the sources built on top of the others
+	)
+	for _, name := range names {
+		switch name {
+		case "registry":
+			if cfg.ChainlinkRegistry == "" {
+				log.Infof("price source %q skipped: no --chainlink-registry", name)
+				continue
+			}
+			feed, err := chainlink.NewFeedRegistry(cfg.ChainlinkRegistry)
+			if err != nil {
+				return nil, err
+			}
+			var bounds *chainlink.AggregatorV3
+			if cfg.CheckBounds {
+				if bounds, err = chainlink.NewAggregatorV3(); err != nil {
+					return nil, err
+				}
+			}
+			out = append(out, service.NewRegistrySource(feed, bounds))
+		case "aggregator":
+			agg, err := chainlink.NewAggregatorV3()
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, service.NewAggregatorSource(agg, cfg.CheckBounds))
+		case "static":
+			if cfg.PricesFile == "" {
+				log.Infof("price source %q skipped: no --prices-file", name)
+				continue
+			}
+			p, err := prices.Load(cfg.PricesFile)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, service.NewStaticSource(p))
+		case "uniswap", "wrapper":
+			composite[name] = len(out)
+			out = append(out, nil)
+		default:
+			return nil, fmt.Errorf("unknown price source %q", name)
+		}
+	}
+	leaves := slices.DeleteFunc(slices.Clone(out), func(s service.PriceSource) bool { return s == nil })
+	eth := ethToken(preset, toks)
+	weth := cfg.WETH
+	if weth == "" {
+		weth = uniswap.MainnetWETH
+	}
+	uniIdx, uni := composite["uniswap"]
+	if uni {
+		pool, err := uniswap.NewPoolV3()
+		if err != nil {
+			return nil, err
+		}
+		out[uniIdx] = service.NewUniswapSource(pool, cfg.TWAPWindow, weth, eth, toks, leaves)
+	}
+	if i, ok := composite["wrapper"]; ok {
+		codec, err := wrapper.NewCodec()
+		if err != nil {
+			return nil, err
+		}
+		// underlying assets may themselves be priced from a pool
+		quotes := slices.Clone(leaves)
+		if uni {
+			quotes = append(quotes, out[uniIdx])
+		}
+		out[i] = service.NewWrapperSource(codec, weth, eth, toks, quotes)
+	}
+	if len(out) == 0 {
+		return nil, errors.New("no price sources configured")
+	}
+	return out, nil
+}
+
+// ethToken is how the Uniswap source prices WETH: the preset's ETH/USD feed, else the
+// feed of a native ETH entry of the token list, else the Feed Registry.
+func ethToken(preset network.Preset, toks []tokens.Token) tokens.Token {
+	t := tokens.Token{Address: chainlink.ETHPseudoAddress, Symbol: chainlink.ETH, Decimals: 18, Feed: preset.ETHFeed}
+	if t.Feed != "" {
+		return t
+	}
+	for _, n := range toks {
+		if chainlink.IsNative(n.Address) && (n.Symbol == "" || n.Symbol == chainlink.ETH) && n.Feed != "" {
+			t.Feed = n.Feed
+			break
+		}
+	}
+	return t
+}
+
+// quoteToken describes the quote currency as a token the price sources can handle:
+// its registry denomination, or an explicit QUOTE/USD aggregator.
+func quoteToken(symbol, feed string) (tokens.Token, error) {
+	if symbol == chainlink.ETH {
+		return tokens.Token{Address: chainlink.ETHPseudoAddress, Symbol: symbol, Feed: feed}, nil
+	}
+	addr, ok := chainlink.Denomination(symbol)
+	if !ok && feed == "" {
+		return tokens.Token{}, fmt.Errorf("unknown quote currency %q (pass --quote-feed)", symbol)
+	}
+	t := tokens.Token{Symbol: symbol, Feed: feed}
+	if ok {
+		t.Address = addr.Hex()
+	}
+	return t, nil
+}
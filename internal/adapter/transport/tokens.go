@@ -0,0 +1,25 @@
+package transport
+
+import (
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/chainlink"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/network"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/tokens"
+)
+
+// loadTokens loads the configured token sources, or the preset's defaults plus the
+// native asset when there are none; with --discover and no defaults that is the native asset alone.
+func loadTokens(chainID uint64, preset network.Preset, files []string) ([]tokens.Token, error) {
+	if len(files) > 0 || preset.ChainID == 0 {
+		return tokens.Load(chainID, files...)
+	}
+	// decimals and feed come from the valuator's native asset
+	toks := []tokens.Token{{Address: chainlink.ETHPseudoAddress, Symbol: preset.Native.Symbol}}
+	if len(preset.Tokens) > 0 {
+		list, err := tokens.Load(chainID, preset.Tokens...)
+		if err != nil {
+			return nil, err
+		}
+		toks = append(toks, list...)
+	}
+	return toks, nil
+}
@@ -0,0 +1,80 @@
+package uniswap
+
+import (
+	"math/big"
+)
+
+var (
+	q96  = new(big.Int).Lsh(big.NewInt(1), 96)
+	q128 = new(big.Int).Lsh(big.NewInt(1), 128)
+	q256 = new(big.Int).Lsh(big.NewInt(1), 256)
+)
+
+// sqrtRatioAtTick returns sqrt(1.0001^tick), unscaled.
+func sqrtRatioAtTick(tick int64) *big.Float {
+	base, _ := new(big.Float).SetPrec(floatPrec).SetString("1.0001")
+	return new(big.Float).SetPrec(floatPrec).Sqrt(powFloat(base, tick))
+}
+
+// AmountsForLiquidity returns the raw token0/token1 amounts of 'liquidity' in [tickLower, tickUpper)
+// at the pool's current sqrt price (Q64.96) and tick, rounded down like the pool does on burn.
+func AmountsForLiquidity(sqrtPriceX96 *big.Int, tick, tickLower, tickUpper int64, liquidity *big.Int) (*big.Int, *big.Int) {
+	l := new(big.Float).SetPrec(floatPrec).SetInt(liquidity)
+	sa, sb := sqrtRatioAtTick(tickLower), sqrtRatioAtTick(tickUpper)
+	sp := new(big.Float).SetPrec(floatPrec).Quo(
+		new(big.Float).SetPrec(floatPrec).SetInt(sqrtPriceX96),
+		new(big.Float).SetPrec(floatPrec).SetInt(q96),
+	)
+
+	// amount0 = L * (upper - lower) / (lower * upper), amount1 = L * (upper - lower)
+	amount0 := func(lo, hi *big.Float) *big.Float {
+		num := new(big.Float).SetPrec(floatPrec).Sub(hi, lo)
+		num.Mul(num, l)
+		den := new(big.Float).SetPrec(floatPrec).Mul(lo, hi)
+		return num.Quo(num, den)
+	}
+	amount1 := func(lo, hi *big.Float) *big.Float {
+		d := new(big.Float).SetPrec(floatPrec).Sub(hi, lo)
+		return d.Mul(d, l)
+	}
+
+	a0, a1 := new(big.Float), new(big.Float)
+	switch {
+	case tick < tickLower:
+		a0 = amount0(sa, sb)
+	case tick >= tickUpper:
+		a1 = amount1(sa, sb)
+	default:
+		a0 = amount0(sp, sb)
+		a1 = amount1(sa, sp)
+	}
+	i0, _ := a0.Int(nil)
+	i1, _ := a1.Int(nil)
+	return i0, i1
+}
+
+// FeeGrowthInside computes the fee growth inside [tickLower, tickUpper) (X128, modulo 2^256)
+// from the pool's global growth and the "outside" growth of both boundary ticks.
+func FeeGrowthInside(global, outsideLower, outsideUpper *big.Int, tick, tickLower, tickUpper int64) *big.Int {
+	below := new(big.Int).Set(outsideLower)
+	if tick < tickLower {
+		below.Sub(global, outsideLower)
+	}
+	above := new(big.Int).Set(outsideUpper)
+	if tick >= tickUpper {
+		above.Sub(global, outsideUpper)
+	}
+	inside := new(big.Int).Sub(global, below)
+	inside.Sub(inside, above)
+	return inside.Mod(inside, q256)
+}
+
+// FeesOwed returns tokensOwed plus the fees accrued since the position was last touched:
+// liquidity * (inside - insideLast) / 2^128, with the subtraction modulo 2^256.
+func FeesOwed(liquidity, inside, insideLast, owed *big.Int) *big.Int {
+	delta := new(big.Int).Sub(inside, insideLast)
+	delta.Mod(delta, q256)
+	fees := delta.Mul(delta, liquidity)
+	fees.Div(fees, q128)
+	return fees.Add(fees, owed)
+}
@@ -0,0 +1,80 @@
+package uniswap
+
+import (
+	"embed"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Встраиваем ABI Uniswap V2 pair (резервы и LP-токен).
+//
+//go:embed abi/pair_v2.json
+var pairFS embed.FS
+
+// PairV2 encodes/decodes calls to Uniswap V2 (and compatible) pairs; one instance serves every pair.
+type PairV2 struct {
+	abi abi.ABI
+}
+
+func NewPairV2() (*PairV2, error) {
+	abiBytes, err := pairFS.ReadFile("abi/pair_v2.json")
+	if err != nil {
+		return nil, err
+	}
+	a, err := abi.JSON(strings.NewReader(string(abiBytes)))
+	if err != nil {
+		return nil, err
+	}
+	return &PairV2{abi: a}, nil
+}
+
+func (p *PairV2) PackGetReserves() ([]byte, error) { return p.abi.Pack("getReserves") }
+
+// UnpackGetReserves returns reserve0 and reserve1.
+func (p *PairV2) UnpackGetReserves(out []byte) (*big.Int, *big.Int, error) {
+	res, err := p.abi.Unpack("getReserves", out)
+	if err != nil || len(res) != 3 {
+		return nil, nil, errors.New("unpack getReserves")
+	}
+	r0, ok1 := res[0].(*big.Int)
+	r1, ok2 := res[1].(*big.Int)
+	if !ok1 || !ok2 {
+		return nil, nil, errors.New("unexpected getReserves types")
+	}
+	return r0, r1, nil
+}
+
+func (p *PairV2) PackTotalSupply() ([]byte, error) { return p.abi.Pack("totalSupply") }
+
+func (p *PairV2) UnpackTotalSupply(out []byte) (*big.Int, error) {
+	res, err := p.abi.Unpack("totalSupply", out)
+	if err != nil || len(res) != 1 {
+		return nil, errors.New("unpack totalSupply")
+	}
+	v, ok := res[0].(*big.Int)
+	if !ok {
+		return nil, errors.New("unexpected totalSupply type")
+	}
+	return v, nil
+}
+
+func (p *PairV2) PackToken0() ([]byte, error) { return p.abi.Pack("token0") }
+
+func (p *PairV2) PackToken1() ([]byte, error) { return p.abi.Pack("token1") }
+
+// UnpackToken decodes the result of token0()/token1().
+func (p *PairV2) UnpackToken(method string, out []byte) (common.Address, error) {
+	res, err := p.abi.Unpack(method, out)
+	if err != nil || len(res) != 1 {
+		return common.Address{}, errors.New("unpack " + method)
+	}
+	addr, ok := res[0].(common.Address)
+	if !ok {
+		return common.Address{}, errors.New("unexpected " + method + " type")
+	}
+	return addr, nil
+}
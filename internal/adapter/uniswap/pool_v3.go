@@ -0,0 +1,186 @@
+package uniswap
+
+import (
+	"embed"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Embedded Uniswap V3 pool ABI: token0/token1, the oracle (observe, slot0) and the
+// feeGrowthGlobal/ticks reads that value LP positions.
+//
+//go:embed abi/pool_v3.json
+var poolFS embed.FS
+
+// WETH on Ethereum mainnet; pools quoted in it are chained through ETH/USD.
+const MainnetWETH = "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
+
+// floatPrec keeps the liquidity math on sqrt prices accurate to well beyond 18 significant digits.
+const floatPrec = 256
+
+// PoolV3 encodes/decodes calls to Uniswap V3 pools; one instance serves every pool.
+type PoolV3 struct {
+	abi abi.ABI
+}
+
+func NewPoolV3() (*PoolV3, error) {
+	abiBytes, err := poolFS.ReadFile("abi/pool_v3.json")
+	if err != nil {
+		return nil, err
+	}
+	a, err := abi.JSON(strings.NewReader(string(abiBytes)))
+	if err != nil {
+		return nil, err
+	}
+	return &PoolV3{abi: a}, nil
+}
+
+func (p *PoolV3) PackToken0() ([]byte, error) { return p.abi.Pack("token0") }
+
+func (p *PoolV3) PackToken1() ([]byte, error) { return p.abi.Pack("token1") }
+
+// UnpackToken decodes the result of token0()/token1().
+func (p *PoolV3) UnpackToken(method string, out []byte) (common.Address, error) {
+	res, err := p.abi.Unpack(method, out)
+	if err != nil || len(res) != 1 {
+		return common.Address{}, errors.New("unpack " + method)
+	}
+	addr, ok := res[0].(common.Address)
+	if !ok {
+		return common.Address{}, errors.New("unexpected " + method + " type")
+	}
+	return addr, nil
+}
+
+// PackObserve asks for tick cumulatives 'window' ago and now.
+func (p *PoolV3) PackObserve(window time.Duration) ([]byte, error) {
+	secs := uint32(window / time.Second)
+	if secs == 0 {
+		return nil, errors.New("twap window must be at least 1s")
+	}
+	return p.abi.Pack("observe", []uint32{secs, 0})
+}
+
+// DecodeMeanTick turns the observe() result into the arithmetic mean tick over the window,
+// rounded towards negative infinity like OracleLibrary.consult.
+func (p *PoolV3) DecodeMeanTick(out []byte, window time.Duration) (int64, error) {
+	res, err := p.abi.Unpack("observe", out)
+	if err != nil || len(res) != 2 {
+		return 0, errors.New("unpack observe")
+	}
+	cums, ok := res[0].([]*big.Int)
+	if !ok || len(cums) != 2 {
+		return 0, errors.New("unexpected observe result")
+	}
+	secs := int64(window / time.Second)
+	delta := new(big.Int).Sub(cums[1], cums[0])
+	tick := new(big.Int)
+	mod := new(big.Int)
+	tick.QuoRem(delta, big.NewInt(secs), mod)
+	if delta.Sign() < 0 && mod.Sign() != 0 {
+		tick.Sub(tick, big.NewInt(1))
+	}
+	return tick.Int64(), nil
+}
+
+// TickRatio returns how many token1 one token0 is worth at 'tick', in human units, as the
+// exact fraction num/den = 1.0001^tick * 10^(dec0-dec1). It is left unreduced: at extreme
+// ticks both parts have millions of digits and a gcd would cost far more than dividing.
+func TickRatio(tick int64, dec0, dec1 uint8) (num, den *big.Int) {
+	n := big.NewInt(tick)
+	n.Abs(n)
+	num = new(big.Int).Exp(big.NewInt(10001), n, nil)
+	den = new(big.Int).Exp(big.NewInt(10000), n, nil)
+	if tick < 0 {
+		num, den = den, num
+	}
+	if scale := int64(dec0) - int64(dec1); scale >= 0 {
+		num.Mul(num, new(big.Int).Exp(big.NewInt(10), big.NewInt(scale), nil))
+	} else {
+		den.Mul(den, new(big.Int).Exp(big.NewInt(10), big.NewInt(-scale), nil))
+	}
+	return num, den
+}
+
+// powFloat computes x^n by squaring; negative n yields 1/x^|n|.
+func powFloat(x *big.Float, n int64) *big.Float {
+	neg := n < 0
+	if neg {
+		n = -n
+	}
+	res := new(big.Float).SetPrec(floatPrec).SetInt64(1)
+	base := new(big.Float).SetPrec(floatPrec).Set(x)
+	for n > 0 {
+		if n&1 == 1 {
+			res.Mul(res, base)
+		}
+		base.Mul(base, base)
+		n >>= 1
+	}
+	if neg {
+		res.Quo(new(big.Float).SetPrec(floatPrec).SetInt64(1), res)
+	}
+	return res
+}
+
+func (p *PoolV3) PackSlot0() ([]byte, error) { return p.abi.Pack("slot0") }
+
+// UnpackSlot0 returns the current sqrt price (Q64.96) and tick of the pool.
+func (p *PoolV3) UnpackSlot0(out []byte) (*big.Int, int64, error) {
+	res, err := p.abi.Unpack("slot0", out)
+	if err != nil || len(res) < 2 {
+		return nil, 0, errors.New("unpack slot0")
+	}
+	sqrtPrice, ok1 := res[0].(*big.Int)
+	tick, ok2 := res[1].(*big.Int)
+	if !ok1 || !ok2 {
+		return nil, 0, errors.New("unexpected slot0 types")
+	}
+	return sqrtPrice, tick.Int64(), nil
+}
+
+// PackFeeGrowthGlobal encodes feeGrowthGlobal0X128 (token 0) or feeGrowthGlobal1X128 (token 1).
+func (p *PoolV3) PackFeeGrowthGlobal(token int) ([]byte, error) {
+	return p.abi.Pack(feeGrowthGlobal(token))
+}
+
+func (p *PoolV3) UnpackFeeGrowthGlobal(token int, out []byte) (*big.Int, error) {
+	method := feeGrowthGlobal(token)
+	res, err := p.abi.Unpack(method, out)
+	if err != nil || len(res) != 1 {
+		return nil, errors.New("unpack " + method)
+	}
+	v, ok := res[0].(*big.Int)
+	if !ok {
+		return nil, errors.New("unexpected " + method + " type")
+	}
+	return v, nil
+}
+
+func feeGrowthGlobal(token int) string {
+	if token == 0 {
+		return "feeGrowthGlobal0X128"
+	}
+	return "feeGrowthGlobal1X128"
+}
+
+func (p *PoolV3) PackTicks(tick int64) ([]byte, error) { return p.abi.Pack("ticks", big.NewInt(tick)) }
+
+// UnpackTicksFeeGrowthOutside returns feeGrowthOutside0X128 and feeGrowthOutside1X128 of a tick.
+func (p *PoolV3) UnpackTicksFeeGrowthOutside(out []byte) (*big.Int, *big.Int, error) {
+	res, err := p.abi.Unpack("ticks", out)
+	if err != nil || len(res) < 4 {
+		return nil, nil, errors.New("unpack ticks")
+	}
+	fo0, ok1 := res[2].(*big.Int)
+	fo1, ok2 := res[3].(*big.Int)
+	if !ok1 || !ok2 {
+		return nil, nil, errors.New("unexpected ticks types")
+	}
+	return fo0, fo1, nil
+}
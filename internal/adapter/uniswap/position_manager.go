@@ -0,0 +1,125 @@
+package uniswap
+
+import (
+	"embed"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Встраиваем ABI NonfungiblePositionManager (+ getPool фабрики V3).
+//
+//go:embed abi/position_manager.json
+var managerFS embed.FS
+
+// PositionManager encodes/decodes calls to the Uniswap V3 NonfungiblePositionManager
+// and to the V3 factory it points to.
+type PositionManager struct {
+	addr common.Address
+	abi  abi.ABI
+}
+
+// PositionV3 is one position NFT as returned by positions(tokenId).
+type PositionV3 struct {
+	Token0, Token1       common.Address
+	Fee                  uint32
+	TickLower, TickUpper int64
+	Liquidity            *big.Int
+	FeeGrowthInside0Last *big.Int // X128
+	FeeGrowthInside1Last *big.Int // X128
+	TokensOwed0          *big.Int
+	TokensOwed1          *big.Int
+}
+
+func NewPositionManager(address string) (*PositionManager, error) {
+	if !common.IsHexAddress(address) {
+		return nil, errors.New("position manager address is not hex: " + address)
+	}
+	abiBytes, err := managerFS.ReadFile("abi/position_manager.json")
+	if err != nil {
+		return nil, err
+	}
+	a, err := abi.JSON(strings.NewReader(string(abiBytes)))
+	if err != nil {
+		return nil, err
+	}
+	return &PositionManager{addr: common.HexToAddress(address), abi: a}, nil
+}
+
+func (m *PositionManager) Address() common.Address { return m.addr }
+
+func (m *PositionManager) PackFactory() ([]byte, error) { return m.abi.Pack("factory") }
+
+// PackGetPool encodes factory.getPool(token0, token1, fee).
+func (m *PositionManager) PackGetPool(token0, token1 common.Address, fee uint32) ([]byte, error) {
+	return m.abi.Pack("getPool", token0, token1, new(big.Int).SetUint64(uint64(fee)))
+}
+
+// UnpackAddress decodes the result of factory() or getPool().
+func (m *PositionManager) UnpackAddress(method string, out []byte) (common.Address, error) {
+	res, err := m.abi.Unpack(method, out)
+	if err != nil || len(res) != 1 {
+		return common.Address{}, errors.New("unpack " + method)
+	}
+	addr, ok := res[0].(common.Address)
+	if !ok {
+		return common.Address{}, errors.New("unexpected " + method + " type")
+	}
+	return addr, nil
+}
+
+func (m *PositionManager) PackBalanceOf(owner common.Address) ([]byte, error) {
+	return m.abi.Pack("balanceOf", owner)
+}
+
+func (m *PositionManager) PackTokenOfOwnerByIndex(owner common.Address, i int64) ([]byte, error) {
+	return m.abi.Pack("tokenOfOwnerByIndex", owner, big.NewInt(i))
+}
+
+// UnpackUint decodes the result of balanceOf or tokenOfOwnerByIndex.
+func (m *PositionManager) UnpackUint(method string, out []byte) (*big.Int, error) {
+	res, err := m.abi.Unpack(method, out)
+	if err != nil || len(res) != 1 {
+		return nil, errors.New("unpack " + method)
+	}
+	v, ok := res[0].(*big.Int)
+	if !ok {
+		return nil, errors.New("unexpected " + method + " type")
+	}
+	return v, nil
+}
+
+func (m *PositionManager) PackPositions(tokenID *big.Int) ([]byte, error) {
+	return m.abi.Pack("positions", tokenID)
+}
+
+func (m *PositionManager) UnpackPositions(out []byte) (PositionV3, error) {
+	res, err := m.abi.Unpack("positions", out)
+	if err != nil || len(res) != 12 {
+		return PositionV3{}, errors.New("unpack positions")
+	}
+	var (
+		p   PositionV3
+		ok  = true
+		num = func(i int) *big.Int {
+			v, isInt := res[i].(*big.Int)
+			ok = ok && isInt
+			return v
+		}
+	)
+	p.Token0, _ = res[2].(common.Address)
+	p.Token1, _ = res[3].(common.Address)
+	fee, lower, upper := num(4), num(5), num(6)
+	p.Liquidity = num(7)
+	p.FeeGrowthInside0Last, p.FeeGrowthInside1Last = num(8), num(9)
+	p.TokensOwed0, p.TokensOwed1 = num(10), num(11)
+	if !ok {
+		return PositionV3{}, errors.New("unexpected positions types")
+	}
+	p.Fee = uint32(fee.Uint64())
+	p.TickLower, p.TickUpper = lower.Int64(), upper.Int64()
+	return p, nil
+}
@@ -0,0 +1,109 @@
+package wrapper
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Встраиваем ABI обёрток: ERC-4626 и курсовые функции LST.
+//
+//go:embed abi/wrapper.json
+var wrapperFS embed.FS
+
+// Rate kinds: how one wrapper token converts into its underlying asset.
+const (
+	ERC4626         = "erc4626"         // convertToAssets(10^decimals), underlying = asset()
+	StEthPerToken   = "stEthPerToken"   // Lido wstETH -> stETH, 1e18-scaled
+	GetExchangeRate = "getExchangeRate" // Rocket Pool rETH -> ETH, 1e18-scaled
+	ExchangeRate    = "exchangeRate"    // Coinbase cbETH -> ETH, 1e18-scaled
+	OneToOne        = "1:1"             // WETH and other plain wrappers, no call needed
+)
+
+// RateScale is the fixed-point scale of the non-ERC-4626 rate functions.
+var RateScale = big.NewInt(1e18)
+
+// Wrapper is a known wrapper token.
+type Wrapper struct {
+	Rate       string
+	Underlying string // token address or "eth://native"; empty means asset()
+}
+
+// Known are the mainnet wrappers recognized without configuration.
+var Known = map[common.Address]Wrapper{
+	common.HexToAddress("0x7f39C581F595B53c5cb19bD0b3f8dA6c935E2Ca0"): {StEthPerToken, "0xae7ab96520DE3A18E5e111B5EaAB095312D7fE84"}, // wstETH -> stETH
+	common.HexToAddress("0xae78736Cd615f374D3085123A210448E74Fc6393"): {GetExchangeRate, "eth://native"},                             // rETH
+	common.HexToAddress("0xBe9895146f7AF43049ca1c1AE358B0541Ea49704"): {ExchangeRate, "eth://native"},                                // cbETH
+	common.HexToAddress("0x83F20F44975D03b1b09e64809B757c47f942BEeA"): {ERC4626, ""},                                                 // sDAI -> DAI
+}
+
+// ValidRate reports whether kind is one of the rate kinds above.
+func ValidRate(kind string) bool {
+	switch kind {
+	case ERC4626, StEthPerToken, GetExchangeRate, ExchangeRate, OneToOne:
+		return true
+	}
+	return false
+}
+
+// Codec encodes/decodes wrapper calls; one instance serves every wrapper.
+type Codec struct {
+	abi abi.ABI
+}
+
+func NewCodec() (*Codec, error) {
+	abiBytes, err := wrapperFS.ReadFile("abi/wrapper.json")
+	if err != nil {
+		return nil, err
+	}
+	a, err := abi.JSON(strings.NewReader(string(abiBytes)))
+	if err != nil {
+		return nil, err
+	}
+	return &Codec{abi: a}, nil
+}
+
+func (c *Codec) PackAsset() ([]byte, error) { return c.abi.Pack("asset") }
+
+// UnpackAsset decodes the result of asset().
+func (c *Codec) UnpackAsset(out []byte) (common.Address, error) {
+	res, err := c.abi.Unpack("asset", out)
+	if err != nil || len(res) != 1 {
+		return common.Address{}, errors.New("unpack asset")
+	}
+	addr, ok := res[0].(common.Address)
+	if !ok {
+		return common.Address{}, errors.New("unexpected asset type")
+	}
+	return addr, nil
+}
+
+func (c *Codec) PackConvertToAssets(shares *big.Int) ([]byte, error) {
+	return c.abi.Pack("convertToAssets", shares)
+}
+
+// PackRate encodes the rate function of a non-ERC-4626 kind.
+func (c *Codec) PackRate(kind string) ([]byte, error) {
+	if kind == ERC4626 || kind == OneToOne || !ValidRate(kind) {
+		return nil, fmt.Errorf("no rate function for %q", kind)
+	}
+	return c.abi.Pack(kind)
+}
+
+// UnpackUint decodes the uint256 result of convertToAssets or a rate function.
+func (c *Codec) UnpackUint(method string, out []byte) (*big.Int, error) {
+	res, err := c.abi.Unpack(method, out)
+	if err != nil || len(res) != 1 {
+		return nil, errors.New("unpack " + method)
+	}
+	v, ok := res[0].(*big.Int)
+	if !ok {
+		return nil, errors.New("unexpected " + method + " type")
+	}
+	return v, nil
+}
@@ -16,7 +16,7 @@ func New(log *logger.Logger, runner Runner) *App {
 }
 
 func (a *App) Start(ctx context.Context, cfg RunConfig) error {
-	a.log.Infof("starting rpc=%s acct=%s fmt=%s", cfg.RPCURL, cfg.Account, cfg.Format)
+	a.log.Infof("starting rpc=%s accounts=%d fmt=%s", cfg.RPCURL, len(cfg.Accounts), cfg.Format)
 	defer a.log.Infof("stopped")
 	return a.runner.Run(ctx, cfg)
 }
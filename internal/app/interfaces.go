@@ -1,6 +1,9 @@
 package app
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 //go:generate mockgen -source=interfaces.go -destination=./interfaces_mock.go -package=app
 
@@ -10,9 +13,42 @@ type Runner interface {
 
 type RunConfig struct {
 	RPCURL            string
-	ChainlinkRegistry string
-	TokensFile        string
-	Account           string
-	Format            string // "text" or "json"
-	Output            string // file path or "" for stdout
+	Network           string        // expected network preset; empty accepts whatever the RPC is on
+	ChainlinkRegistry string        // defaults to the preset's registry (mainnet only)
+	TokensFiles       []string      // token files or "builtin:<name>" lists, merged in order
+	PriceSources      []string      // ordered fallback chain: aggregator|registry|uniswap|static
+	PricesFile        string        // static USD prices for the "static" source
+	NFTCollections    string        // NFT collections to list holdings of
+	NFTFloors         string        // floor prices of the collections, in ETH or USD
+	TWAPWindow        time.Duration // Uniswap V3 TWAP window, overridable per token
+	WETH              string        // wrapped native token; Uniswap pools quoted in it go through ETH/USD
+	Accounts          []string
+	AccountsFile      string        // optional file with one account per line, merged with Accounts
+	Discover          bool          // add tokens found in incoming Transfer logs
+	DiscoverFrom      uint64        // first block of the log scan
+	DiscoverChunk     uint64        // initial eth_getLogs block range
+	Block             string        // block number, hash, or latest|finalized|safe; "" means latest
+	At                time.Time     // if set, value at the last block not after this time (overrides Block)
+	Positions         []string      // protocols to read: aave|compound|uniswap-v2|uniswap-v3
+	AaveDataProvider  string        // defaults to the preset's
+	Comets            []string      // Compound V3 markets; default to the preset's
+	PositionManager   string        // Uniswap V3 NonfungiblePositionManager; defaults to the preset's
+	Multicall         bool          // batch reads through Multicall3
+	MulticallAddress  string        // Multicall3 contract; default eth.Multicall3Address
+	Concurrency       int           // tokens valued in parallel; <=1 means sequential
+	Quote             string        // output currency (EUR, GBP, JPY, ETH, BTC, ...); "" or USD means USD
+	QuoteFeed         string        // optional QUOTE/USD AggregatorV3 proxy, required off-registry
+	Rounding          string        // half-even|half-away|truncate; "" means half-even
+	Heartbeat         time.Duration // default max price age; tokens file "heartbeat" overrides
+	CheckBounds       bool          // compare Chainlink answers with the aggregator's minAnswer/maxAnswer
+	Strict            bool          // fail the run on any failure class
+	FailOn            string        // comma-separated failure classes that fail the run: stale,incomplete,bound,noprice,rpc,config
+	Format            string        // text|json|csv|markdown, see service.LookupFormat
+	CSVDelimiter      string        // one character or "tab"; default ","
+	CSVNoHeader       bool          // omit the CSV header record
+	Output            string        // file path or "" for stdout
+	Listen            string        // HTTP listen address for server mode
+	Timeout           time.Duration // per-request timeout in server mode, per run in exporter mode
+	Interval          time.Duration // time between valuation runs in exporter mode
+	Watch             time.Duration // CLI: re-value every Watch (or per new block over WebSocket) and print changes
 }
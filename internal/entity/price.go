@@ -1,11 +1,32 @@
 package entity
 
-import "time"
+import (
+	"math/big"
+	"time"
+)
 
 type Price struct {
-	Base      string
-	Quote     string // "USD"
-	Value     string // decimal string
-	Stale     bool
-	UpdatedAt time.Time
+	Base       string
+	Quote      string // "USD"
+	Value      string // decimal string
+	Stale      bool
+	UpdatedAt  time.Time // zero for sources without a notion of freshness
+	Source     string    // which price source produced the value
+	Round      *Round    // Chainlink round behind the price, nil for other sources
+	AtBound    bool      // answer sits at the aggregator's minAnswer/maxAnswer (circuit breaker)
+	Incomplete bool      // some round behind a derived price is incomplete, even if Round is not
+}
+
+// Round is the full latestRoundData result of a Chainlink feed.
+type Round struct {
+	RoundID         *big.Int
+	Answer          *big.Int
+	StartedAt       time.Time
+	UpdatedAt       time.Time
+	AnsweredInRound *big.Int
+}
+
+// Incomplete reports a round whose answer was carried over from an earlier round.
+func (r *Round) Incomplete() bool {
+	return r != nil && r.RoundID != nil && r.AnsweredInRound != nil && r.AnsweredInRound.Cmp(r.RoundID) < 0
 }
@@ -1,71 +1,146 @@
 package service
 
 import (
-	"math"
+	"errors"
+	"fmt"
 	"math/big"
-	"strconv"
 	"strings"
 )
 
-// FormatAmount converts integer 'raw' with decimals to decimal string with up to 'precision' fractional digits.
-func FormatAmount(raw *big.Int, decimals int, precision int) string {
-	if raw == nil {
-		return "0"
+// RoundingMode decides how a Decimal is cut to a fixed number of fractional digits.
+type RoundingMode int
+
+const (
+	RoundHalfEven         RoundingMode = iota // banker's rounding, for accounting
+	RoundHalfAwayFromZero                     // 0.5 -> 1, -0.5 -> -1
+	RoundTruncate                             // drop extra digits (towards zero)
+)
+
+// ParseRoundingMode accepts "half-even", "half-away" and "truncate".
+func ParseRoundingMode(s string) (RoundingMode, error) {
+	switch s {
+	case "half-even":
+		return RoundHalfEven, nil
+	case "half-away":
+		return RoundHalfAwayFromZero, nil
+	case "truncate":
+		return RoundTruncate, nil
+	default:
+		return 0, fmt.Errorf("unknown rounding mode %q (half-even|half-away|truncate)", s)
 	}
-	if decimals == 0 {
-		return raw.String()
+}
+
+// Decimal is an exact rational number; amounts, prices, USD values and totals are all
+// kept as Decimals and only rounded when printed. The zero value is 0.
+type Decimal struct {
+	r *big.Rat
+}
+
+// NewDecimal returns raw / 10^decimals, e.g. a token balance in human units.
+func NewDecimal(raw *big.Int, decimals int) Decimal {
+	if raw == nil {
+		return Decimal{}
 	}
-	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
-	r := new(big.Rat).SetFrac(raw, scale)
-	return FormatRat(r, precision)
+	return Decimal{r: new(big.Rat).SetFrac(raw, pow10(decimals))}
 }
 
-// FormatRat prints big.Rat with fixed max precision, trimming trailing zeros.
-func FormatRat(r *big.Rat, precision int) string {
+// DecimalFromRat copies r.
+func DecimalFromRat(r *big.Rat) Decimal {
 	if r == nil {
-		return "0"
+		return Decimal{}
+	}
+	return Decimal{r: new(big.Rat).Set(r)}
+}
+
+// ParseDecimal parses a plain decimal string ("123.45", "-0.001").
+func ParseDecimal(s string) (Decimal, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return Decimal{}, errors.New("invalid decimal: " + s)
+	}
+	return Decimal{r: r}, nil
+}
+
+func (d Decimal) rat() *big.Rat {
+	if d.r == nil {
+		return new(big.Rat)
+	}
+	return d.r
+}
+
+// Rat returns a copy of the exact value.
+func (d Decimal) Rat() *big.Rat { return new(big.Rat).Set(d.rat()) }
+
+func (d Decimal) Sign() int { return d.rat().Sign() }
+
+func (d Decimal) Cmp(o Decimal) int { return d.rat().Cmp(o.rat()) }
+
+func (d Decimal) Add(o Decimal) Decimal { return Decimal{r: new(big.Rat).Add(d.rat(), o.rat())} }
+
+func (d Decimal) Sub(o Decimal) Decimal { return Decimal{r: new(big.Rat).Sub(d.rat(), o.rat())} }
+
+func (d Decimal) Mul(o Decimal) Decimal { return Decimal{r: new(big.Rat).Mul(d.rat(), o.rat())} }
+
+func (d Decimal) Neg() Decimal { return Decimal{r: new(big.Rat).Neg(d.rat())} }
+
+// Quo divides exactly; o must not be zero.
+func (d Decimal) Quo(o Decimal) (Decimal, error) {
+	if o.Sign() == 0 {
+		return Decimal{}, errors.New("division by zero")
 	}
-	// scale by 10^precision and round
-	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(precision)), nil)
-	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(scale))
-	i := new(big.Int)
-	scaledFloat := new(big.Float).SetRat(scaled)
-	scaledFloat.SetMode(big.ToNearestAway)
-	scaledFloat.Int(i) // rounded
-
-	intPart := new(big.Int).Quo(i, scale)
-	fracPart := new(big.Int).Mod(i, scale)
-
-	if precision == 0 {
-		return intPart.String()
+	return Decimal{r: new(big.Rat).Quo(d.rat(), o.rat())}, nil
+}
+
+// Round returns d rounded to 'precision' fractional digits.
+func (d Decimal) Round(precision int, mode RoundingMode) Decimal {
+	return quoRound(d.rat().Num(), d.rat().Denom(), precision, mode)
+}
+
+// quoRound returns num/den (den > 0) rounded to 'precision' fractional digits, without
+// ever building the exact fraction: handy when num and den are too large to reduce.
+func quoRound(num, den *big.Int, precision int, mode RoundingMode) Decimal {
+	scale := pow10(precision)
+	num = new(big.Int).Mul(num, scale)
+
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int)) // truncated towards zero
+	if rem.Sign() != 0 && mode != RoundTruncate {
+		// compare 2*|rem| with den to find which side of the half we are on
+		twice := new(big.Int).Abs(rem)
+		twice.Lsh(twice, 1)
+		c := twice.Cmp(den)
+		up := c > 0 || (c == 0 && (mode == RoundHalfAwayFromZero || q.Bit(0) == 1))
+		if up {
+			if num.Sign() < 0 {
+				q.Sub(q, big.NewInt(1))
+			} else {
+				q.Add(q, big.NewInt(1))
+			}
+		}
 	}
+	return Decimal{r: new(big.Rat).SetFrac(q, scale)}
+}
 
-	s := fracPart.Text(10)
-	// left pad
-	if len(s) < precision {
-		s = strings.Repeat("0", precision-len(s)) + s
+// StringFixed rounds to at most 'precision' fractional digits and trims trailing zeros.
+func (d Decimal) StringFixed(precision int, mode RoundingMode) string {
+	rounded := d.Round(precision, mode).rat()
+	s := rounded.FloatString(precision) // exact: the value has at most 'precision' digits now
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
 	}
-	// trim trailing zeros
-	s = strings.TrimRight(s, "0")
-	if s == "" {
-		return intPart.String()
+	if s == "-0" {
+		s = "0"
 	}
-	return intPart.String() + "." + s
+	return s
 }
 
-// MulDecimalStrings multiplies two decimal strings (a,b) and returns decimal string with 'precision' digits after dot.
-func MulDecimalStrings(a, b string, precision int) string {
-	af, _, _ := big.ParseFloat(a, 10, 256, big.ToNearestAway)
-	bf, _, _ := big.ParseFloat(b, 10, 256, big.ToNearestAway)
-	res := new(big.Float).Mul(af, bf)
-	// round to precision
-	pow := math.Pow10(precision)
-	f64, _ := res.Float64()
-	f64 = math.Round(f64*pow) / pow
-	return strings.TrimRight(strings.TrimRight(fmtFloat(f64, precision), "0"), ".")
+// String prints the exact value when it has a finite decimal expansion, otherwise 18 digits.
+func (d Decimal) String() string {
+	if n, exact := d.rat().FloatPrec(); exact {
+		return d.StringFixed(n, RoundHalfEven)
+	}
+	return d.StringFixed(18, RoundHalfEven)
 }
 
-func fmtFloat(f float64, prec int) string {
-	// 'f' format with fixed prec
-	return strconv.FormatFloat(f, 'f', prec, 64)
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
 }
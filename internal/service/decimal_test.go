@@ -0,0 +1,235 @@
+package service
+
+import (
+	"math/big"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestRoundingModes(t *testing.T) {
+	tests := []struct {
+		in        string
+		precision int
+		halfEven  string
+		halfAway  string
+		truncate  string
+	}{
+		{"2.5", 0, "2", "3", "2"},
+		{"3.5", 0, "4", "4", "3"},
+		{"-2.5", 0, "-2", "-3", "-2"},
+		{"-3.5", 0, "-4", "-4", "-3"},
+		{"0.5", 0, "0", "1", "0"},
+		{"-0.5", 0, "0", "-1", "0"},
+		{"2.4999999", 0, "2", "2", "2"},
+		{"2.5000001", 0, "3", "3", "2"},
+		{"-2.5000001", 0, "-3", "-3", "-2"},
+		{"1.005", 2, "1", "1.01", "1"},
+		{"1.015", 2, "1.02", "1.02", "1.01"},
+		{"-1.015", 2, "-1.02", "-1.02", "-1.01"},
+		{"0.0000005", 6, "0", "0.000001", "0"},
+		{"0.0000015", 6, "0.000002", "0.000002", "0.000001"},
+		{"123.456", 6, "123.456", "123.456", "123.456"},
+		{"0", 2, "0", "0", "0"},
+		// above 2^53, where float64 can no longer hold every integer
+		{"9007199254740993.5", 0, "9007199254740994", "9007199254740994", "9007199254740993"},
+		{"9007199254740992.5", 0, "9007199254740992", "9007199254740993", "9007199254740992"},
+		{"-9007199254740993.5", 0, "-9007199254740994", "-9007199254740994", "-9007199254740993"},
+		{"115792089237316195423570985008687907853269984665640564039457.584007913129639935", 6,
+			"115792089237316195423570985008687907853269984665640564039457.584008",
+			"115792089237316195423570985008687907853269984665640564039457.584008",
+			"115792089237316195423570985008687907853269984665640564039457.584007"},
+	}
+	for _, tt := range tests {
+		d, err := ParseDecimal(tt.in)
+		if err != nil {
+			t.Fatalf("ParseDecimal(%q): %v", tt.in, err)
+		}
+		for mode, want := range map[RoundingMode]string{
+			RoundHalfEven:         tt.halfEven,
+			RoundHalfAwayFromZero: tt.halfAway,
+			RoundTruncate:         tt.truncate,
+		} {
+			if got := d.StringFixed(tt.precision, mode); got != want {
+				t.Errorf("StringFixed(%s, %d, mode %d) = %s, want %s", tt.in, tt.precision, mode, got, want)
+			}
+		}
+	}
+}
+
+func TestParseRoundingMode(t *testing.T) {
+	for s, want := range map[string]RoundingMode{
+		"half-even": RoundHalfEven,
+		"half-away": RoundHalfAwayFromZero,
+		"truncate":  RoundTruncate,
+	} {
+		got, err := ParseRoundingMode(s)
+		if err != nil || got != want {
+			t.Errorf("ParseRoundingMode(%q) = %v, %v; want %v", s, got, err, want)
+		}
+	}
+	if _, err := ParseRoundingMode("up"); err == nil {
+		t.Error("ParseRoundingMode(up): want error")
+	}
+}
+
+// randomRat returns ±n/10^k with n up to 2^256, i.e. a terminating decimal of any magnitude.
+func randomRat(r *rand.Rand) (*big.Rat, int) {
+	n := new(big.Int)
+	for range 4 {
+		n.Lsh(n, 64).Or(n, new(big.Int).SetUint64(r.Uint64()))
+	}
+	n.Rsh(n, uint(r.IntN(256)))
+	if r.IntN(2) == 0 {
+		n.Neg(n)
+	}
+	k := r.IntN(30)
+	return new(big.Rat).SetFrac(n, pow10(k)), k
+}
+
+func TestParseDecimalRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	for range 2000 {
+		want, k := randomRat(r)
+		s := want.FloatString(k)
+		d, err := ParseDecimal(s)
+		if err != nil {
+			t.Fatalf("ParseDecimal(%q): %v", s, err)
+		}
+		if d.Rat().Cmp(want) != 0 {
+			t.Fatalf("ParseDecimal(%q) = %s, want %s", s, d.Rat().RatString(), want.RatString())
+		}
+		// printing the exact value and parsing it again loses nothing
+		back, err := ParseDecimal(d.String())
+		if err != nil || back.Cmp(d) != 0 {
+			t.Fatalf("String round trip of %q: got %q", s, d.String())
+		}
+	}
+}
+
+func TestNewDecimalExact(t *testing.T) {
+	r := rand.New(rand.NewPCG(3, 4))
+	for range 2000 {
+		want, k := randomRat(r)
+		raw := new(big.Int).Mul(want.Num(), new(big.Int).Quo(pow10(k), want.Denom()))
+		if got := NewDecimal(raw, k); got.Rat().Cmp(want) != 0 {
+			t.Fatalf("NewDecimal(%s, %d) = %s, want %s", raw, k, got.Rat().RatString(), want.RatString())
+		}
+	}
+}
+
+// Sums of decimals are exact: adding n values then subtracting them gives back 0,
+// and 0.1 + 0.2 is 0.3.
+func TestDecimalArithmeticExact(t *testing.T) {
+	a, _ := ParseDecimal("0.1")
+	b, _ := ParseDecimal("0.2")
+	c, _ := ParseDecimal("0.3")
+	if a.Add(b).Cmp(c) != 0 {
+		t.Fatalf("0.1 + 0.2 = %s", a.Add(b))
+	}
+
+	r := rand.New(rand.NewPCG(5, 6))
+	var (
+		sum  Decimal
+		vals []Decimal
+	)
+	for range 500 {
+		v, _ := randomRat(r)
+		d := DecimalFromRat(v)
+		vals = append(vals, d)
+		sum = sum.Add(d)
+	}
+	for _, d := range vals {
+		sum = sum.Sub(d)
+	}
+	if sum.Sign() != 0 {
+		t.Fatalf("sum - parts = %s, want 0", sum)
+	}
+}
+
+// Properties of Round for every mode: the result has at most 'precision' digits,
+// is within half a unit (a whole unit when truncating) of the input, rounding is
+// idempotent, and truncation never grows the magnitude.
+func TestRoundProperties(t *testing.T) {
+	r := rand.New(rand.NewPCG(7, 8))
+	for range 3000 {
+		v, _ := randomRat(r)
+		d := DecimalFromRat(v)
+		p := r.IntN(20)
+		unit := new(big.Rat).SetFrac(big.NewInt(1), pow10(p))
+		half := new(big.Rat).Mul(unit, big.NewRat(1, 2))
+
+		for _, mode := range []RoundingMode{RoundHalfEven, RoundHalfAwayFromZero, RoundTruncate} {
+			got := d.Round(p, mode)
+
+			scaled := new(big.Rat).Mul(got.Rat(), new(big.Rat).SetInt(pow10(p)))
+			if !scaled.IsInt() {
+				t.Fatalf("Round(%s, %d, %d) = %s has more than %d digits", d, p, mode, got, p)
+			}
+
+			diff := new(big.Rat).Sub(got.Rat(), v)
+			diff.Abs(diff)
+			limit := half
+			if mode == RoundTruncate {
+				limit = unit
+				if diff.Cmp(limit) >= 0 {
+					t.Fatalf("Truncate(%s, %d) = %s is a unit or more away", d, p, got)
+				}
+				if new(big.Rat).Abs(got.Rat()).Cmp(new(big.Rat).Abs(v)) > 0 {
+					t.Fatalf("Truncate(%s, %d) = %s grew the magnitude", d, p, got)
+				}
+			} else if diff.Cmp(limit) > 0 {
+				t.Fatalf("Round(%s, %d, %d) = %s is more than half a unit away", d, p, mode, got)
+			}
+
+			if again := got.Round(p, mode); again.Cmp(got) != 0 {
+				t.Fatalf("Round is not idempotent: %s -> %s -> %s", d, got, again)
+			}
+			if got.Sign() != 0 && got.Sign() != d.Sign() {
+				t.Fatalf("Round(%s, %d, %d) = %s flipped the sign", d, p, mode, got)
+			}
+		}
+	}
+}
+
+// On exact ties half-even lands on an even last digit and half-away moves away from zero.
+func TestRoundTies(t *testing.T) {
+	r := rand.New(rand.NewPCG(9, 10))
+	for range 1000 {
+		n := new(big.Int).SetUint64(r.Uint64())
+		n.Lsh(n, uint(r.IntN(80))) // well above 2^53
+		if r.IntN(2) == 0 {
+			n.Neg(n)
+		}
+		p := r.IntN(10)
+		// n + 0.5 units at precision p
+		tie := new(big.Rat).SetFrac(new(big.Int).Add(new(big.Int).Mul(n, big.NewInt(2)), big.NewInt(int64(n.Sign()|1))), new(big.Int).Mul(pow10(p), big.NewInt(2)))
+		d := DecimalFromRat(tie)
+
+		even := new(big.Rat).Mul(d.Round(p, RoundHalfEven).Rat(), new(big.Rat).SetInt(pow10(p)))
+		if even.Num().Bit(0) != 0 {
+			t.Fatalf("half-even(%s, %d) = %s is odd", d, p, d.Round(p, RoundHalfEven))
+		}
+		away := d.Round(p, RoundHalfAwayFromZero).Rat()
+		if new(big.Rat).Abs(away).Cmp(new(big.Rat).Abs(tie)) <= 0 {
+			t.Fatalf("half-away(%s, %d) = %s did not move away from zero", d, p, away.FloatString(p))
+		}
+	}
+}
+
+// quoRound on an unreduced fraction matches rounding the reduced one.
+func TestQuoRoundUnreduced(t *testing.T) {
+	r := rand.New(rand.NewPCG(11, 12))
+	for range 1000 {
+		v, _ := randomRat(r)
+		k := new(big.Int).SetUint64(r.Uint64() | 1)
+		num := new(big.Int).Mul(v.Num(), k)
+		den := new(big.Int).Mul(v.Denom(), k)
+		p := r.IntN(20)
+		for _, mode := range []RoundingMode{RoundHalfEven, RoundHalfAwayFromZero, RoundTruncate} {
+			want := DecimalFromRat(v).Round(p, mode)
+			if got := quoRound(num, den, p, mode); got.Cmp(want) != 0 {
+				t.Fatalf("quoRound(%s/%s, %d, %d) = %s, want %s", num, den, p, mode, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RowChange is one difference between two valuations of the same row
+// (account, token and position); Before or After is nil for added or removed rows.
+type RowChange struct {
+	Account string
+	Asset   string // symbol, with the position in parentheses
+	Before  *ValuationRow
+	After   *ValuationRow
+}
+
+// TotalChange is a changed account total, or the grand total when Account is empty.
+type TotalChange struct {
+	Account       string
+	Before, After string
+}
+
+// ResultDiff is what changed between two valuation results.
+type ResultDiff struct {
+	Block     uint64
+	BlockTime time.Time
+	Rows      []RowChange
+	Totals    []TotalChange
+}
+
+// Empty reports whether nothing but the block changed.
+func (d ResultDiff) Empty() bool { return len(d.Rows) == 0 && len(d.Totals) == 0 }
+
+func rowAsset(row ValuationRow) string {
+	if row.Position != "" {
+		return row.Symbol + " (" + row.Position + ")"
+	}
+	return row.Symbol
+}
+
+// DiffResults compares cur with prev row by row: amounts, prices, values and errors,
+// plus the account and grand totals. Rows are matched by account, token contract and
+// position, so tokens sharing a symbol are kept apart.
+func DiffResults(prev, cur ValuationResult) ResultDiff {
+	d := ResultDiff{Block: cur.Block, BlockTime: cur.BlockTime}
+
+	key := func(row ValuationRow) string { return row.Account + "\x00" + row.Token + "\x00" + row.Position }
+	old := make(map[string]*ValuationRow, len(prev.Rows))
+	for i := range prev.Rows {
+		old[key(prev.Rows[i])] = &prev.Rows[i]
+	}
+	seen := make(map[string]bool, len(cur.Rows))
+	for i := range cur.Rows {
+		row := &cur.Rows[i]
+		k := key(*row)
+		seen[k] = true
+		before, ok := old[k]
+		switch {
+		case !ok:
+			d.Rows = append(d.Rows, RowChange{Account: row.Account, Asset: rowAsset(*row), After: row})
+		case before.Amount != row.Amount || before.Price != row.Price || before.USD != row.USD ||
+			before.Value != row.Value || before.Err != row.Err:
+			d.Rows = append(d.Rows, RowChange{Account: row.Account, Asset: rowAsset(*row), Before: before, After: row})
+		}
+	}
+	for i := range prev.Rows {
+		row := &prev.Rows[i]
+		if !seen[key(*row)] {
+			d.Rows = append(d.Rows, RowChange{Account: row.Account, Asset: rowAsset(*row), Before: row})
+		}
+	}
+
+	accTotal, total := func(a AccountTotal) string { return a.TotalUSD }, func(r ValuationResult) string { return r.TotalUSD }
+	if cur.Quote != "" {
+		accTotal, total = func(a AccountTotal) string { return a.Total }, func(r ValuationResult) string { return r.Total }
+	}
+	prevTotals := make(map[string]string, len(prev.Accounts))
+	for _, a := range prev.Accounts {
+		prevTotals[a.Account] = accTotal(a)
+	}
+	if len(cur.Accounts) > 1 {
+		for _, a := range cur.Accounts {
+			if before := prevTotals[a.Account]; before != accTotal(a) {
+				d.Totals = append(d.Totals, TotalChange{Account: a.Account, Before: before, After: accTotal(a)})
+			}
+		}
+	}
+	if total(prev) != total(cur) {
+		d.Totals = append(d.Totals, TotalChange{Before: total(prev), After: total(cur)})
+	}
+	return d
+}
+
+// ANSI colors of FormatDiff
+const (
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiReset = "\x1b[0m"
+)
+
+// FormatDiff prints the changes of d, one line each: "+" added, "-" removed, "~" changed.
+// With color, increases are green and decreases red.
+func FormatDiff(d ResultDiff, currency string, color bool) string {
+	paint := func(before, after string) string {
+		s := before + " -> " + after
+		delta, ok := deltaString(before, after)
+		if ok {
+			s += " (" + delta + ")"
+		}
+		if !color || !ok {
+			return s
+		}
+		if strings.HasPrefix(delta, "-") {
+			return ansiRed + s + ansiReset
+		}
+		return ansiGreen + s + ansiReset
+	}
+
+	value := func(r *ValuationRow) string {
+		if r.Value != "" {
+			return r.Value
+		}
+		return r.USD
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "BLOCK: %d (%s)\n", d.Block, d.BlockTime.UTC().Format(time.RFC3339))
+	for _, c := range d.Rows {
+		switch {
+		case c.Before == nil:
+			fmt.Fprintf(&b, "+ %s %s %s = %s %s\n", c.Account, c.Asset, c.After.Amount, value(c.After), currency)
+		case c.After == nil:
+			fmt.Fprintf(&b, "- %s %s\n", c.Account, c.Asset)
+		default:
+			var parts []string
+			if c.Before.Amount != c.After.Amount {
+				parts = append(parts, "amount "+paint(c.Before.Amount, c.After.Amount))
+			}
+			if c.Before.Price != c.After.Price {
+				parts = append(parts, "price "+paint(c.Before.Price, c.After.Price))
+			}
+			if value(c.Before) != value(c.After) {
+				parts = append(parts, currency+" "+paint(value(c.Before), value(c.After)))
+			}
+			if c.Before.Err != c.After.Err {
+				parts = append(parts, fmt.Sprintf("error %q -> %q", c.Before.Err, c.After.Err))
+			}
+			fmt.Fprintf(&b, "~ %s %s %s\n", c.Account, c.Asset, strings.Join(parts, ", "))
+		}
+	}
+	for _, t := range d.Totals {
+		if t.Account == "" {
+			fmt.Fprintf(&b, "TOTAL %s: %s\n", currency, paint(t.Before, t.After))
+		} else {
+			fmt.Fprintf(&b, "TOTAL %s %s: %s\n", currency, t.Account, paint(t.Before, t.After))
+		}
+	}
+	return b.String()
+}
+
+// deltaString is after - before when both are numbers.
+func deltaString(before, after string) (string, bool) {
+	a, err := ParseDecimal(before)
+	if err != nil {
+		return "", false
+	}
+	b, err := ParseDecimal(after)
+	if err != nil {
+		return "", false
+	}
+	delta := b.Sub(a)
+	s := delta.String()
+	if delta.Sign() >= 0 {
+		s = "+" + s
+	}
+	return s, true
+}
@@ -1,8 +1,26 @@
 package service
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrStalePrice = errors.New("stale price")
 	ErrNoPrice    = errors.New("no price available")
+
+	ErrIncompleteRound = errors.New("incomplete round: answeredInRound < roundId")
+	ErrPriceAtBound    = errors.New("price at circuit-breaker bound (minAnswer/maxAnswer)")
+	ErrPriceOutOfRange = errors.New("price outside configured bounds")
 )
+
+// ConfigError is a mistake in the run's input (an account, a token entry, a block spec)
+// rather than a failed read; retrying won't help.
+type ConfigError struct{ Err error }
+
+func (e *ConfigError) Error() string { return e.Err.Error() }
+func (e *ConfigError) Unwrap() error { return e.Err }
+
+func configErrorf(format string, args ...any) error {
+	return &ConfigError{Err: fmt.Errorf(format, args...)}
+}
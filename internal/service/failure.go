@@ -0,0 +1,157 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FailureClass classifies why a row could not be valued cleanly.
+type FailureClass string
+
+const (
+	FailStale      FailureClass = "stale"      // price older than its heartbeat
+	FailIncomplete FailureClass = "incomplete" // answeredInRound < roundId
+	FailBound      FailureClass = "bound"      // price clamped by the feed or outside user bounds
+	FailNoPrice    FailureClass = "noprice"    // no source could price the token
+	FailRPC        FailureClass = "rpc"        // balance/metadata/price reads failed
+	FailConfig     FailureClass = "config"     // bad account, token entry or override
+)
+
+// failureOrder lists classes from most to least severe; it also fixes their exit codes.
+var failureOrder = []FailureClass{FailConfig, FailRPC, FailNoPrice, FailBound, FailIncomplete, FailStale}
+
+var exitCodes = map[FailureClass]int{
+	FailStale:      3,
+	FailNoPrice:    4,
+	FailRPC:        5,
+	FailIncomplete: 6,
+	FailBound:      7,
+	FailConfig:     8,
+}
+
+// FailurePolicy is the set of classes that fail a run; the zero value fails nothing.
+type FailurePolicy map[FailureClass]bool
+
+// StrictPolicy fails on every class.
+func StrictPolicy() FailurePolicy {
+	p := FailurePolicy{}
+	for _, c := range failureOrder {
+		p[c] = true
+	}
+	return p
+}
+
+// ParseFailOn parses a comma-separated list such as "stale,noprice,rpc".
+func ParseFailOn(s string) (FailurePolicy, error) {
+	p := FailurePolicy{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		c := FailureClass(part)
+		if _, ok := exitCodes[c]; !ok {
+			return nil, fmt.Errorf("unknown failure class %q (stale|incomplete|bound|noprice|rpc|config)", part)
+		}
+		p[c] = true
+	}
+	return p, nil
+}
+
+// Classify maps the error of a row that could not be valued to its failure class.
+func Classify(err error) FailureClass {
+	var cfg *ConfigError
+	switch {
+	case errors.As(err, &cfg):
+		return FailConfig
+	case errors.Is(err, ErrNoPrice):
+		return FailNoPrice
+	default:
+		// JSON-RPC, HTTP and network errors, timeouts, and replies that don't decode
+		return FailRPC
+	}
+}
+
+// FailedRow is one offending row in a FailureError summary.
+type FailedRow struct {
+	Account string       `json:"account"`
+	Symbol  string       `json:"symbol"`
+	Class   FailureClass `json:"class"`
+	Error   string       `json:"error"`
+}
+
+// FailureError is returned when a run violates its FailurePolicy.
+// It also carries errors that stop a run before any row is valued, see NewFailure.
+type FailureError struct {
+	ExitCode int                  `json:"exit_code"`
+	Class    FailureClass         `json:"class"` // most severe class found
+	Counts   map[FailureClass]int `json:"counts"`
+	Rows     []FailedRow          `json:"rows"`
+	Message  string               `json:"error,omitempty"` // set by NewFailure
+
+	err error
+}
+
+// NewFailure wraps an error that aborts the whole run, such as a failed connection,
+// so that it exits with the code of class c regardless of the policy.
+func NewFailure(c FailureClass, err error) *FailureError {
+	return &FailureError{ExitCode: exitCodes[c], Class: c, Counts: map[FailureClass]int{}, Message: err.Error(), err: err}
+}
+
+func (e *FailureError) Error() string {
+	if e.err != nil {
+		return e.err.Error()
+	}
+	return fmt.Sprintf("%d row(s) failed policy, most severe: %s", len(e.Rows), e.Class)
+}
+
+func (e *FailureError) Unwrap() error { return e.err }
+
+// Summary is the machine-readable (JSON) form of the failure.
+func (e *FailureError) Summary() string {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return e.Error()
+	}
+	return string(b)
+}
+
+// Check returns a *FailureError if any row falls into a class of the policy, nil otherwise.
+func (p FailurePolicy) Check(res ValuationResult) error {
+	if len(p) == 0 {
+		return nil
+	}
+	fe := &FailureError{Counts: map[FailureClass]int{}}
+	for _, row := range res.Rows {
+		if row.ErrClass == "" || !p[row.ErrClass] {
+			continue
+		}
+		fe.Counts[row.ErrClass]++
+		fe.Rows = append(fe.Rows, FailedRow{Account: row.Account, Symbol: row.Symbol, Class: row.ErrClass, Error: row.Err})
+	}
+	for _, h := range res.NFTs {
+		if h.ErrClass == "" || !p[h.ErrClass] {
+			continue
+		}
+		fe.Counts[h.ErrClass]++
+		fe.Rows = append(fe.Rows, FailedRow{Account: h.Account, Symbol: h.Collection, Class: h.ErrClass, Error: h.Err})
+	}
+	// every converted value depends on the quote price
+	if res.QuoteClass != "" && p[res.QuoteClass] {
+		fe.Counts[res.QuoteClass]++
+		fe.Rows = append(fe.Rows, FailedRow{Symbol: res.Quote, Class: res.QuoteClass, Error: "quote: " + res.QuoteErr})
+	}
+	if len(fe.Rows) == 0 {
+		return nil
+	}
+	for _, c := range failureOrder {
+		if fe.Counts[c] > 0 {
+			fe.Class = c
+			break
+		}
+	}
+	fe.ExitCode = exitCodes[fe.Class]
+	return fe
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"slices"
+	"strings"
+)
+
+// FormatOptions tune the formats that have options; the zero value is the default.
+type FormatOptions struct {
+	CSVDelimiter rune // default ','
+	CSVNoHeader  bool
+}
+
+// Format is a registered output format.
+type Format struct {
+	ContentType string // for the HTTP API
+	Render      func(r ValuationResult, opts FormatOptions) (string, error)
+}
+
+var formats = map[string]Format{
+	"text":     {ContentType: "text/plain; charset=utf-8", Render: ignoreOptions(FormatText)},
+	"json":     {ContentType: "application/json", Render: ignoreOptions(FormatJSON)},
+	"csv":      {ContentType: "text/csv; charset=utf-8", Render: FormatCSV},
+	"markdown": {ContentType: "text/markdown; charset=utf-8", Render: ignoreOptions(FormatMarkdown)},
+}
+
+func ignoreOptions(f func(ValuationResult) (string, error)) func(ValuationResult, FormatOptions) (string, error) {
+	return func(r ValuationResult, _ FormatOptions) (string, error) { return f(r) }
+}
+
+// RegisterFormat adds or replaces an output format.
+func RegisterFormat(name string, f Format) { formats[name] = f }
+
+// LookupFormat finds a registered format; unknown names are an error, never a fallback.
+func LookupFormat(name string) (Format, error) {
+	f, ok := formats[name]
+	if !ok {
+		return Format{}, configErrorf("unknown format %q (%s)", name, strings.Join(FormatNames(), "|"))
+	}
+	return f, nil
+}
+
+// FormatNames lists the registered formats, sorted.
+func FormatNames() []string {
+	names := make([]string, 0, len(formats))
+	for n := range formats {
+		names = append(names, n)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormatCSV writes one RFC 4180 record per row (CRLF line ends, quoted where needed).
+// NFT holdings follow the fungible rows with position "nft"; totals are left to the spreadsheet.
+func FormatCSV(r ValuationResult, opts FormatOptions) (string, error) {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.UseCRLF = true
+	if opts.CSVDelimiter != 0 {
+		w.Comma = opts.CSVDelimiter
+	}
+
+	quote := r.Quote != ""
+	if !opts.CSVNoHeader {
+		header := []string{"block", "account", "asset", "position", "amount", "usd"}
+		if quote {
+			header = append(header, strings.ToLower(r.Quote))
+		}
+		header = append(header, "price", "source", "updated_at", "error", "error_class")
+		if err := w.Write(header); err != nil {
+			return "", err
+		}
+	}
+
+	block := ""
+	if r.Block != 0 {
+		block = strconv.FormatUint(r.Block, 10)
+	}
+	record := func(account, asset, position, amount, usd, value, price, source, updated, errMsg, class string) error {
+		rec := []string{block, account, asset, position, amount, usd}
+		if quote {
+			rec = append(rec, value)
+		}
+		return w.Write(append(rec, price, source, updated, errMsg, class))
+	}
+
+	for _, row := range r.Rows {
+		updated := ""
+		if !row.UpdatedAt.IsZero() {
+			updated = row.UpdatedAt.UTC().Format(time.RFC3339)
+		}
+		if err := record(row.Account, row.Symbol, row.Position, row.Amount, row.USD, row.Value, row.Price, row.Source, updated, row.Err, string(row.ErrClass)); err != nil {
+			return "", err
+		}
+	}
+	for _, h := range r.NFTs {
+		asset := h.Collection
+		if h.TokenID != "" {
+			asset += " #" + h.TokenID
+		}
+		floor := ""
+		if h.Floor != "" {
+			floor = h.Floor + " " + h.FloorCurrency
+		}
+		if err := record(h.Account, asset, "nft", h.Amount, h.USD, h.Value, floor, "floor", "", h.Err, string(h.ErrClass)); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	return b.String(), w.Error()
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FormatMarkdown renders a GitHub-flavored table with right-aligned numbers and a total row.
+func FormatMarkdown(r ValuationResult) (string, error) {
+	cur := "USD"
+	rowValue := func(row ValuationRow) string { return row.USD }
+	accTotal := func(a AccountTotal) string { return a.TotalUSD }
+	total := r.TotalUSD
+	if r.Quote != "" {
+		cur = r.Quote
+		rowValue = func(row ValuationRow) string { return row.Value }
+		accTotal = func(a AccountTotal) string { return a.Total }
+		total = r.Total
+	}
+
+	var b strings.Builder
+	if r.Block != 0 {
+		fmt.Fprintf(&b, "Block **%d** (%s)\n\n", r.Block, r.BlockTime.UTC().Format(time.RFC3339))
+	}
+	fmt.Fprintf(&b, "| Account | Asset | Amount | %s | Source | Error |\n", cur)
+	b.WriteString("| --- | --- | ---: | ---: | --- | --- |\n")
+	totalLabel := "**Total**"
+	for _, row := range r.Rows {
+		asset := row.Symbol
+		if row.Position != "" {
+			asset += " (" + row.Position + ")"
+			// borrows are negative, so totals are net worth
+			totalLabel = "**Total (net)**"
+		}
+		mdRow(&b, "`"+row.Account+"`", asset, row.Amount, rowValue(row), row.Source, row.Err)
+	}
+	if len(r.Accounts) > 1 {
+		for _, a := range r.Accounts {
+			mdRow(&b, "`"+a.Account+"`", totalLabel, "", accTotal(a), "", strings.TrimSpace(incompleteNote(a.Incomplete)))
+		}
+	}
+	mdRow(&b, totalLabel, "", "", "**"+total+"**", "", strings.TrimSpace(incompleteNote(r.Incomplete)))
+
+	if len(r.NFTs) > 0 {
+		nftValue := func(h NFTHolding) string { return h.USD }
+		nftTotal := r.NFTTotalUSD
+		if r.Quote != "" {
+			nftValue = func(h NFTHolding) string { return h.Value }
+			nftTotal = r.NFTTotal
+		}
+		b.WriteString("\n**NFTs** (floor prices, not in the total above)\n\n")
+		fmt.Fprintf(&b, "| Account | Collection | Token ID | Amount | Floor | %s | Error |\n", cur)
+		b.WriteString("| --- | --- | --- | ---: | ---: | ---: | --- |\n")
+		for _, h := range r.NFTs {
+			floor := ""
+			if h.Floor != "" {
+				floor = h.Floor + " " + h.FloorCurrency
+			}
+			mdRow(&b, "`"+h.Account+"`", h.Collection, h.TokenID, h.Amount, floor, nftValue(h), h.Err)
+		}
+		mdRow(&b, "**Total**", "", "", "", "", "**"+nftTotal+"**", "")
+	}
+
+	if r.Quote != "" {
+		fmt.Fprintf(&b, "\n1 %s = %s USD (%s)%s\n", r.Quote, r.QuoteUSD, r.QuoteSource, quoteNote(r))
+	}
+	return b.String(), nil
+}
+
+// mdRow writes one table row, escaping what would break the table.
+func mdRow(b *strings.Builder, cells ...string) {
+	b.WriteString("|")
+	for _, c := range cells {
+		c = strings.ReplaceAll(c, "|", `\|`)
+		c = strings.ReplaceAll(c, "\n", " ")
+		b.WriteString(" " + c + " |")
+	}
+	b.WriteString("\n")
+}
@@ -3,15 +3,89 @@ package service
 import (
 	"fmt"
 	"strings"
+	"time"
 )
 
 // FormatText TODO поправить игнор ошибки
 func FormatText(r ValuationResult) (string, error) {
+	// with --quote the value column and totals are in the quote currency
+	cur := "USD"
+	rowValue := func(row ValuationRow) string { return row.USD }
+	accTotal := func(a AccountTotal) string { return a.TotalUSD }
+	total := r.TotalUSD
+	if r.Quote != "" {
+		cur = r.Quote
+		rowValue = func(row ValuationRow) string { return row.Value }
+		accTotal = func(a AccountTotal) string { return a.Total }
+		total = r.Total
+	}
+
 	var b strings.Builder
-	fmt.Fprintf(&b, "ASSET\tAMOUNT\tUSD\tSOURCE\tERROR\n")
+	if r.Block != 0 {
+		fmt.Fprintf(&b, "BLOCK: %d (%s)\n\n", r.Block, r.BlockTime.UTC().Format(time.RFC3339))
+	}
+	fmt.Fprintf(&b, "ACCOUNT\tASSET\tAMOUNT\t%s\tSOURCE\tERROR\n", cur)
+	net := false
 	for _, row := range r.Rows {
-		fmt.Fprintf(&b, "%s\t%s\t%s\t%s\t%s\n", row.Symbol, row.Amount, row.USD, row.Source, row.Err)
+		asset := row.Symbol
+		if row.Position != "" {
+			asset += " (" + row.Position + ")"
+			net = true
+		}
+		fmt.Fprintf(&b, "%s\t%s\t%s\t%s\t%s\t%s\n", row.Account, asset, row.Amount, rowValue(row), row.Source, row.Err)
+	}
+	if net {
+		// borrows are negative, so totals are net worth
+		cur += " (net)"
+	}
+	if len(r.Accounts) > 1 {
+		fmt.Fprintf(&b, "\n")
+		for _, a := range r.Accounts {
+			fmt.Fprintf(&b, "TOTAL %s %s: %s%s\n", cur, a.Account, accTotal(a), incompleteNote(a.Incomplete))
+		}
+	}
+	fmt.Fprintf(&b, "\nTOTAL %s: %s%s\n", cur, total, incompleteNote(r.Incomplete))
+	if len(r.NFTs) > 0 {
+		nftValue := func(h NFTHolding) string { return h.USD }
+		nftTotal := r.NFTTotalUSD
+		if r.Quote != "" {
+			nftValue = func(h NFTHolding) string { return h.Value }
+			nftTotal = r.NFTTotal
+		}
+		// NFTs are listed apart, their floor values are not in the totals above
+		fmt.Fprintf(&b, "\nNFTs (floor prices, not in TOTAL)\n")
+		fmt.Fprintf(&b, "ACCOUNT\tCOLLECTION\tTOKEN ID\tAMOUNT\tFLOOR\t%s\tERROR\n", strings.TrimSuffix(cur, " (net)"))
+		for _, h := range r.NFTs {
+			id := h.TokenID
+			if id == "" {
+				id = "-"
+			}
+			floor := "-"
+			if h.Floor != "" {
+				floor = h.Floor + " " + h.FloorCurrency
+			}
+			fmt.Fprintf(&b, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", h.Account, h.Collection, id, h.Amount, floor, nftValue(h), h.Err)
+		}
+		fmt.Fprintf(&b, "\nTOTAL NFTs %s (floor): %s\n", strings.TrimSuffix(cur, " (net)"), nftTotal)
+	}
+	if r.Quote != "" {
+		fmt.Fprintf(&b, "1 %s = %s USD (%s)%s\n", r.Quote, r.QuoteUSD, r.QuoteSource, quoteNote(r))
 	}
-	fmt.Fprintf(&b, "\nTOTAL USD: %s\n", r.TotalUSD)
 	return b.String(), nil
 }
+
+// quoteNote flags a quote price that failed its own checks.
+func quoteNote(r ValuationResult) string {
+	if r.QuoteErr != "" {
+		return " [" + string(r.QuoteClass) + ": " + r.QuoteErr + "]"
+	}
+	return ""
+}
+
+// incompleteNote flags a net total that leaves out a failed debt row.
+func incompleteNote(incomplete bool) string {
+	if incomplete {
+		return " (incomplete: a debt position failed)"
+	}
+	return ""
+}
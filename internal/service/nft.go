@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/eth"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/nft"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/tokens"
+)
+
+// maxEnumerated caps tokenOfOwnerByIndex calls per collection; larger holdings are listed as one count.
+const maxEnumerated = 200
+
+// NFTHolding is what an account holds of one NFT collection: a single ERC-721 token,
+// an ERC-1155 id with its amount, or a bare ERC-721 count when ids can't be enumerated.
+// Floor values are reported apart from the fungible totals.
+type NFTHolding struct {
+	Account       string
+	Collection    string // name from the collections file, or the address
+	Address       string
+	Standard      string
+	TokenID       string `json:",omitempty"` // empty for a bare count
+	Amount        string
+	Floor         string       `json:",omitempty"` // floor price per token, in FloorCurrency
+	FloorCurrency string       `json:",omitempty"`
+	USD           string       // Amount * floor; "0" without a floor
+	Value         string       `json:",omitempty"` // in the quote currency
+	Err           string       `json:",omitempty"`
+	ErrClass      FailureClass `json:",omitempty"`
+
+	usd Decimal
+}
+
+// WithNFTs returns a copy of the valuator that also lists the NFT holdings of every account
+// in collections and values them at the floors; ETH floors are priced as eth.
+func (v *Valuator) WithNFTs(codec *nft.Codec, collections []nft.Collection, floors map[common.Address]nft.Floor, eth tokens.Token) *Valuator {
+	c := *v
+	c.nftCodec = codec
+	c.nfts = collections
+	c.floors = floors
+	c.nftETH = eth
+	return &c
+}
+
+// nftHoldings lists and values the holdings of accounts; a failing collection becomes one error entry.
+func (v *Valuator) nftHoldings(ctx context.Context, accounts []string) ([]NFTHolding, Decimal, error) {
+	var (
+		out    []NFTHolding
+		total  Decimal
+		ethUSD *Decimal // priced once, on the first ETH floor
+	)
+	floorUSD := func(f nft.Floor) (Decimal, error) {
+		price, err := ParseDecimal(f.Price)
+		if err != nil {
+			return Decimal{}, configErrorf("floor price %q: %w", f.Price, err)
+		}
+		if f.Currency == nft.USD {
+			return price, nil
+		}
+		if ethUSD == nil {
+			p, err := v.price(ctx, v.nftETH)
+			if err != nil {
+				return Decimal{}, fmt.Errorf("ETH price: %w", err)
+			}
+			d, err := ParseDecimal(p.Value)
+			if err != nil {
+				return Decimal{}, err
+			}
+			ethUSD = &d
+		}
+		return price.Mul(*ethUSD), nil
+	}
+
+	for _, account := range accounts {
+		acc := common.HexToAddress(account)
+		for _, c := range v.nfts {
+			addr := common.HexToAddress(c.Address)
+			held, err := v.readNFTs(ctx, acc, c)
+			if ctx.Err() != nil {
+				return nil, Decimal{}, ctx.Err()
+			}
+			if err != nil {
+				v.log.Errorf("account %s collection %s: %v", account, c.Name, err)
+				out = append(out, NFTHolding{
+					Account: acc.Hex(), Collection: c.Name, Address: addr.Hex(), Standard: c.Standard,
+					Amount: "0", USD: "0", Err: err.Error(), ErrClass: Classify(err),
+				})
+				continue
+			}
+
+			f, hasFloor := v.floors[addr]
+			for _, h := range held {
+				h.USD = "0"
+				if hasFloor {
+					h.Floor, h.FloorCurrency = f.Price, f.Currency
+					unit, err := floorUSD(f)
+					if err != nil {
+						h.Err, h.ErrClass = err.Error(), Classify(err)
+					} else {
+						amount, _ := ParseDecimal(h.Amount)
+						h.usd = amount.Mul(unit)
+						h.USD = h.usd.StringFixed(2, v.rounding)
+						total = total.Add(h.usd)
+					}
+				}
+				out = append(out, h)
+			}
+		}
+	}
+	return out, total, nil
+}
+
+// readNFTs lists the holdings of acc in one collection, without values.
+func (v *Valuator) readNFTs(ctx context.Context, acc common.Address, c nft.Collection) ([]NFTHolding, error) {
+	addr := common.HexToAddress(c.Address)
+	call := func(data []byte, err error) ([]byte, error) {
+		if err != nil {
+			return nil, err
+		}
+		return v.eth.Call(ctx, addr, data, v.block)
+	}
+	holding := func(id *big.Int, amount *big.Int) NFTHolding {
+		h := NFTHolding{Account: acc.Hex(), Collection: c.Name, Address: addr.Hex(), Standard: c.Standard, Amount: amount.String()}
+		if id != nil {
+			h.TokenID = id.String()
+		}
+		return h
+	}
+	ids, err := c.IDs()
+	if err != nil {
+		return nil, err
+	}
+	one := big.NewInt(1)
+
+	if c.Standard == nft.ERC1155 {
+		out, err := call(v.nftCodec.PackBalanceOfBatch(acc, ids))
+		if err != nil {
+			return nil, err
+		}
+		balances, err := v.nftCodec.UnpackBalanceOfBatch(out)
+		if err != nil {
+			return nil, err
+		}
+		if len(balances) != len(ids) {
+			return nil, fmt.Errorf("balanceOfBatch returned %d balances for %d ids", len(balances), len(ids))
+		}
+		var held []NFTHolding
+		for i, b := range balances {
+			if b.Sign() > 0 {
+				held = append(held, holding(ids[i], b))
+			}
+		}
+		return held, nil
+	}
+
+	// ERC-721: configured ids are checked with ownerOf
+	if len(ids) > 0 {
+		var held []NFTHolding
+		for _, id := range ids {
+			out, err := call(v.nftCodec.PackOwnerOf(id))
+			if eth.IsReverted(err) {
+				continue // burned or never minted
+			}
+			if err != nil {
+				return nil, fmt.Errorf("ownerOf(%s): %w", id, err)
+			}
+			owner, err := v.nftCodec.UnpackOwnerOf(out)
+			if err != nil {
+				return nil, err
+			}
+			if owner == acc {
+				held = append(held, holding(id, one))
+			}
+		}
+		return held, nil
+	}
+
+	out, err := call(v.nftCodec.PackBalanceOf(acc))
+	if err != nil {
+		return nil, err
+	}
+	n, err := v.nftCodec.UnpackUint("balanceOf", out)
+	if err != nil {
+		return nil, err
+	}
+	if n.Sign() == 0 {
+		return nil, nil
+	}
+	if n.Cmp(big.NewInt(maxEnumerated)) > 0 {
+		return []NFTHolding{holding(nil, n)}, nil
+	}
+	// ERC-721 Enumerable; collections without it are listed as a bare count
+	held := make([]NFTHolding, 0, n.Int64())
+	for i := range n.Int64() {
+		out, err := call(v.nftCodec.PackTokenOfOwnerByIndex(acc, i))
+		if eth.IsReverted(err) {
+			return []NFTHolding{holding(nil, n)}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("tokenOfOwnerByIndex(%d): %w", i, err)
+		}
+		id, err := v.nftCodec.UnpackUint("tokenOfOwnerByIndex", out)
+		if err != nil {
+			return []NFTHolding{holding(nil, n)}, nil
+		}
+		held = append(held, holding(id, one))
+	}
+	return held, nil
+}
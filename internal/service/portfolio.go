@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/tokens"
+)
+
+// ValueAccounts values every token for every account and fills per-account and grand totals.
+// Per-token failures become error rows; only context cancellation aborts the run.
+func (v *Valuator) ValueAccounts(ctx context.Context, accounts []string, toks []tokens.Token) (ValuationResult, error) {
+	var readerErrs []error
+	if len(v.positions) > 0 {
+		toks, readerErrs = v.withoutReceiptTokens(ctx, toks)
+	}
+	if v.multicall != nil {
+		v = v.prefetch(ctx, accounts, toks)
+	}
+
+	type job struct {
+		idx      int
+		account  string
+		token    tokens.Token
+		position PositionReader // set for protocol positions instead of token
+		posErr   error          // the position reader failed before reading this account
+	}
+	jobs := make([]job, 0, len(accounts)*(len(toks)+len(v.positions)))
+	for _, acc := range accounts {
+		for _, t := range toks {
+			jobs = append(jobs, job{idx: len(jobs), account: acc, token: t})
+		}
+		for i, r := range v.positions {
+			jobs = append(jobs, job{idx: len(jobs), account: acc, position: r, posErr: readerErrs[i]})
+		}
+	}
+
+	// workers write into their own slot, so Rows keep the input order
+	slots := make([][]ValuationRow, len(jobs))
+	queue := make(chan job)
+	var wg sync.WaitGroup
+	for range min(v.workers(), max(len(jobs), 1)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				switch {
+				case j.posErr != nil:
+					slots[j.idx] = v.readerFailed(j.account, j.position, j.posErr)
+				case j.position != nil:
+					slots[j.idx] = v.positionRows(ctx, j.account, j.position)
+				default:
+					slots[j.idx] = []ValuationRow{v.valueRow(ctx, j.account, j.token)}
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, j := range jobs {
+		select {
+		case <-ctx.Done():
+			break feed
+		case queue <- j:
+		}
+	}
+	close(queue)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return ValuationResult{}, err
+	}
+
+	// discovered tokens only matter where they are actually held; ones whose
+	// balance or metadata can't be read are usually not ERC-20s at all
+	var rows []ValuationRow
+	for i, slot := range slots {
+		for _, row := range slot {
+			if jobs[i].token.Discovered && (row.zero || row.ErrClass == FailRPC) {
+				continue
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	res := ValuationResult{Rows: rows}
+	res.Accounts, res.totalUSD = Totals(res.Rows, v.rounding)
+	res.TotalUSD = res.totalUSD.StringFixed(2, v.rounding)
+	res.Incomplete = slices.ContainsFunc(res.Accounts, func(a AccountTotal) bool { return a.Incomplete })
+	if len(v.nfts) > 0 {
+		var err error
+		if res.NFTs, res.nftUSD, err = v.nftHoldings(ctx, accounts); err != nil {
+			return ValuationResult{}, err
+		}
+		res.NFTTotalUSD = res.nftUSD.StringFixed(2, v.rounding)
+	}
+	if v.quote != "" {
+		if err := v.convert(ctx, &res); err != nil {
+			return ValuationResult{}, err
+		}
+	}
+	return res, nil
+}
+
+// valueRow wraps ValueOne and turns its error into an error row.
+func (v *Valuator) valueRow(ctx context.Context, account string, t tokens.Token) ValuationRow {
+	row, err := v.ValueOne(ctx, account, t)
+	if err != nil {
+		if t.Discovered && Classify(err) == FailRPC {
+			v.log.Infof("account %s: skipping discovered token %s: %v", account, t.Address, err)
+		} else {
+			v.log.Errorf("account %s token %s: %v", account, t.Symbol, err)
+		}
+		return ValuationRow{
+			Account:  account,
+			Symbol:   t.Symbol,
+			Amount:   "0",
+			USD:      "0",
+			Source:   "error",
+			Err:      err.Error(),
+			ErrClass: Classify(err),
+			Token:    tokenKey(t),
+		}
+	}
+	return row
+}
+
+// Totals sums the exact USD of rows without errors, per account (in first-seen order) and overall.
+// An account with a failed debt row is marked Incomplete: its net total leaves the debt out.
+func Totals(rows []ValuationRow, mode RoundingMode) ([]AccountTotal, Decimal) {
+	var (
+		order      []string
+		sums       = make(map[string]Decimal)
+		incomplete = make(map[string]bool)
+		total      Decimal
+	)
+	for _, row := range rows {
+		if _, ok := sums[row.Account]; !ok {
+			sums[row.Account] = Decimal{}
+			order = append(order, row.Account)
+		}
+		if row.Err != "" {
+			if row.liability {
+				incomplete[row.Account] = true
+			}
+			continue
+		}
+		sums[row.Account] = sums[row.Account].Add(row.usd)
+		total = total.Add(row.usd)
+	}
+
+	accounts := make([]AccountTotal, 0, len(order))
+	for _, acc := range order {
+		accounts = append(accounts, AccountTotal{
+			Account:    acc,
+			TotalUSD:   sums[acc].StringFixed(2, mode),
+			Incomplete: incomplete[acc],
+			usd:        sums[acc],
+		})
+	}
+	return accounts, total
+}
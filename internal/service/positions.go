@@ -0,0 +1,380 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/aave"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/compound"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/eth"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/tokens"
+)
+
+// Position kinds; borrows are valued as negative amounts.
+const (
+	PositionSupply     = "supply"
+	PositionCollateral = "collateral"
+	PositionBorrow     = "borrow"
+	PositionLP         = "lp"   // one leg of a liquidity position
+	PositionFees       = "fees" // uncollected LP fees
+)
+
+// Position is an account's balance inside a protocol, in units of Token.
+type Position struct {
+	Protocol string // e.g. "aave-v3", "compound-v3", "uniswap-v3"
+	Market   string // optional: the pair or position NFT, e.g. "WETH/USDC #12345"
+	Kind     string // supply | collateral | borrow | lp | fees
+	Token    tokens.Token
+	Amount   *big.Int // raw, never negative
+}
+
+// PositionQuery is what a PositionReader needs to read one account.
+type PositionQuery struct {
+	Account common.Address
+	Eth     *eth.Client
+	Block   *big.Int // nil means latest
+}
+
+// PositionReader lists the non-zero positions of an account in one protocol.
+type PositionReader interface {
+	Name() string
+	Positions(ctx context.Context, q PositionQuery) ([]Position, error)
+}
+
+// ReceiptTokenReader is a PositionReader whose positions are also ERC-20 balances of the
+// account (Aave aTokens and debt tokens); ValueAccounts leaves those tokens out of the
+// balance rows so each position is valued once.
+type ReceiptTokenReader interface {
+	PositionReader
+	ReceiptTokens(ctx context.Context, ethc *eth.Client, block *big.Int) ([]common.Address, error)
+}
+
+// WithPositions returns a copy of the valuator that also values protocol positions of every account.
+func (v *Valuator) WithPositions(readers ...PositionReader) *Valuator {
+	c := *v
+	c.positions = readers
+	return &c
+}
+
+// positionRows values the positions of one reader. A failing reader becomes one error row,
+// a position that can't be valued an error row of its own.
+func (v *Valuator) positionRows(ctx context.Context, account string, r PositionReader) []ValuationRow {
+	acc := common.HexToAddress(account)
+	positions, err := r.Positions(ctx, PositionQuery{Account: acc, Eth: v.eth, Block: v.block})
+	if err != nil {
+		return v.readerFailed(account, r, err)
+	}
+
+	rows := make([]ValuationRow, 0, len(positions))
+	for _, p := range positions {
+		row, err := v.positionRow(ctx, acc, p)
+		if err != nil {
+			v.log.Errorf("account %s positions %s: %s %s: %v", account, r.Name(), p.Kind, p.Token.Symbol, err)
+			sym := p.Token.Symbol
+			if sym == "" {
+				sym = "-"
+			}
+			row = ValuationRow{
+				Account:  acc.Hex(),
+				Symbol:   sym,
+				Amount:   "0",
+				USD:      "0",
+				Source:   "error",
+				Err:      err.Error(),
+				ErrClass: Classify(err),
+				Token:    tokenKey(p.Token),
+			}
+		}
+		row.liability = p.Kind == PositionBorrow
+		row.Position = p.Protocol + " " + p.Kind
+		if p.Market != "" {
+			row.Position = p.Protocol + " " + p.Market + " " + p.Kind
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// positionRow values one position; borrows come out negative.
+func (v *Valuator) positionRow(ctx context.Context, acc common.Address, p Position) (ValuationRow, error) {
+	t, decimals, err := v.tokenMeta(ctx, p.Token)
+	if err != nil {
+		return ValuationRow{}, err
+	}
+	raw := p.Amount
+	if p.Kind == PositionBorrow {
+		raw = new(big.Int).Neg(raw)
+	}
+	return v.valueAmount(ctx, acc, t, t.Symbol, raw, decimals)
+}
+
+// readerFailed is the single error row of a reader that could not list its positions.
+func (v *Valuator) readerFailed(account string, r PositionReader, err error) []ValuationRow {
+	v.log.Errorf("account %s positions %s: %v", account, r.Name(), err)
+	return []ValuationRow{{
+		Account:   account,
+		Symbol:    "-",
+		Amount:    "0",
+		USD:       "0",
+		Position:  r.Name(),
+		Source:    "error",
+		Err:       err.Error(),
+		ErrClass:  Classify(err),
+		liability: true, // the reader may have had borrows to report
+	}}
+}
+
+// withoutReceiptTokens drops the tokens that a position reader already values. A reader
+// whose receipt tokens can't be listed gets an error in errs (indexed like v.positions):
+// its positions would be counted a second time in the balance rows, so it is failed instead.
+func (v *Valuator) withoutReceiptTokens(ctx context.Context, toks []tokens.Token) (out []tokens.Token, errs []error) {
+	errs = make([]error, len(v.positions))
+	skip := make(map[common.Address]bool)
+	for i, r := range v.positions {
+		rr, ok := r.(ReceiptTokenReader)
+		if !ok {
+			continue
+		}
+		addrs, err := rr.ReceiptTokens(ctx, v.eth, v.block)
+		if err != nil {
+			errs[i] = fmt.Errorf("receipt tokens: %w", err)
+			continue
+		}
+		for _, a := range addrs {
+			skip[a] = true
+		}
+	}
+	if len(skip) == 0 {
+		return toks, errs
+	}
+	return slices.DeleteFunc(slices.Clone(toks), func(t tokens.Token) bool {
+		return common.IsHexAddress(t.Address) && skip[common.HexToAddress(t.Address)]
+	}), errs
+}
+
+// tokenMeta fills the symbol and decimals of an ERC-20 from the chain when unset.
+func (v *Valuator) tokenMeta(ctx context.Context, t tokens.Token) (tokens.Token, uint8, error) {
+	addr := common.HexToAddress(t.Address)
+	if t.Decimals == 0 {
+		dec, err := v.eth.ERC20Decimals(ctx, addr, v.block)
+		if err != nil {
+			return t, 0, err
+		}
+		t.Decimals = int(dec)
+	}
+	if t.Symbol == "" {
+		if s, err := v.eth.ERC20Symbol(ctx, addr, v.block); err == nil && s != "" {
+			t.Symbol = s
+		} else {
+			t.Symbol = "TKN"
+		}
+	}
+	return t, uint8(t.Decimals), nil
+}
+
+// knownTokens indexes the configured ERC-20 tokens by address.
+func knownTokens(list []tokens.Token) map[common.Address]tokens.Token {
+	m := make(map[common.Address]tokens.Token, len(list))
+	for _, t := range list {
+		if common.IsHexAddress(t.Address) {
+			m[common.HexToAddress(t.Address)] = t
+		}
+	}
+	return m
+}
+
+// tokenFor returns the configured entry for addr, or a bare one.
+func tokenFor(known map[common.Address]tokens.Token, addr common.Address) tokens.Token {
+	if t, ok := known[addr]; ok {
+		return t
+	}
+	return tokens.Token{Address: addr.Hex()}
+}
+
+// AaveReader reads Aave V3 supplies and variable/stable debt through the PoolDataProvider.
+type AaveReader struct {
+	provider *aave.DataProvider
+	known    map[common.Address]tokens.Token
+}
+
+func NewAaveReader(provider *aave.DataProvider, list []tokens.Token) *AaveReader {
+	return &AaveReader{provider: provider, known: knownTokens(list)}
+}
+
+func (r *AaveReader) Name() string { return "aave-v3" }
+
+func (r *AaveReader) reserves(ctx context.Context, ethc *eth.Client, block *big.Int) ([]aave.Reserve, error) {
+	data, err := r.provider.PackGetAllReservesTokens()
+	if err != nil {
+		return nil, err
+	}
+	out, err := ethc.Call(ctx, r.provider.Address(), data, block)
+	if err != nil {
+		return nil, err
+	}
+	return r.provider.UnpackGetAllReservesTokens(out)
+}
+
+// ReceiptTokens lists the aToken and debt tokens of every reserve.
+func (r *AaveReader) ReceiptTokens(ctx context.Context, ethc *eth.Client, block *big.Int) ([]common.Address, error) {
+	reserves, err := r.reserves(ctx, ethc, block)
+	if err != nil {
+		return nil, err
+	}
+	var addrs []common.Address
+	for _, res := range reserves {
+		data, err := r.provider.PackGetReserveTokensAddresses(res.TokenAddress)
+		if err != nil {
+			return nil, err
+		}
+		out, err := ethc.Call(ctx, r.provider.Address(), data, block)
+		if err != nil {
+			return nil, fmt.Errorf("reserve %s: %w", res.Symbol, err)
+		}
+		rt, err := r.provider.UnpackGetReserveTokensAddresses(out)
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, rt.AToken, rt.StableDebtToken, rt.VariableDebtToken)
+	}
+	return addrs, nil
+}
+
+func (r *AaveReader) Positions(ctx context.Context, q PositionQuery) ([]Position, error) {
+	reserves, err := r.reserves(ctx, q.Eth, q.Block)
+	if err != nil {
+		return nil, err
+	}
+
+	var positions []Position
+	for _, res := range reserves {
+		data, err := r.provider.PackGetUserReserveData(res.TokenAddress, q.Account)
+		if err != nil {
+			return nil, err
+		}
+		out, err := q.Eth.Call(ctx, r.provider.Address(), data, q.Block)
+		if err != nil {
+			return nil, fmt.Errorf("reserve %s: %w", res.Symbol, err)
+		}
+		ur, err := r.provider.UnpackGetUserReserveData(out)
+		if err != nil {
+			return nil, err
+		}
+
+		t := tokenFor(r.known, res.TokenAddress)
+		if t.Symbol == "" {
+			t.Symbol = res.Symbol
+		}
+		if ur.Supplied.Sign() > 0 {
+			positions = append(positions, Position{Protocol: r.Name(), Kind: PositionSupply, Token: t, Amount: ur.Supplied})
+		}
+		if debt := new(big.Int).Add(ur.StableDebt, ur.VariableDebt); debt.Sign() > 0 {
+			positions = append(positions, Position{Protocol: r.Name(), Kind: PositionBorrow, Token: t, Amount: debt})
+		}
+	}
+	return positions, nil
+}
+
+// CometReader reads Compound V3 markets: the base asset supplied or borrowed and the collateral.
+type CometReader struct {
+	comet   *compound.Comet
+	markets []common.Address
+	known   map[common.Address]tokens.Token
+}
+
+func NewCometReader(comet *compound.Comet, markets []common.Address, list []tokens.Token) *CometReader {
+	return &CometReader{comet: comet, markets: markets, known: knownTokens(list)}
+}
+
+func (r *CometReader) Name() string { return "compound-v3" }
+
+func (r *CometReader) Positions(ctx context.Context, q PositionQuery) ([]Position, error) {
+	var positions []Position
+	for _, market := range r.markets {
+		ps, err := r.market(ctx, q, market)
+		if err != nil {
+			return nil, fmt.Errorf("market %s: %w", market.Hex(), err)
+		}
+		positions = append(positions, ps...)
+	}
+	return positions, nil
+}
+
+func (r *CometReader) market(ctx context.Context, q PositionQuery, market common.Address) ([]Position, error) {
+	call := func(data []byte, err error) ([]byte, error) {
+		if err != nil {
+			return nil, err
+		}
+		return q.Eth.Call(ctx, market, data, q.Block)
+	}
+
+	out, err := call(r.comet.PackBaseToken())
+	if err != nil {
+		return nil, err
+	}
+	base, err := r.comet.UnpackBaseToken(out)
+	if err != nil {
+		return nil, err
+	}
+
+	var positions []Position
+	out, err = call(r.comet.PackBalanceOf(q.Account))
+	if err != nil {
+		return nil, err
+	}
+	supplied, err := r.comet.UnpackAmount("balanceOf", out)
+	if err != nil {
+		return nil, err
+	}
+	if supplied.Sign() > 0 {
+		positions = append(positions, Position{Protocol: r.Name(), Kind: PositionSupply, Token: tokenFor(r.known, base), Amount: supplied})
+	}
+
+	out, err = call(r.comet.PackBorrowBalanceOf(q.Account))
+	if err != nil {
+		return nil, err
+	}
+	borrowed, err := r.comet.UnpackAmount("borrowBalanceOf", out)
+	if err != nil {
+		return nil, err
+	}
+	if borrowed.Sign() > 0 {
+		positions = append(positions, Position{Protocol: r.Name(), Kind: PositionBorrow, Token: tokenFor(r.known, base), Amount: borrowed})
+	}
+
+	// collateral assets
+	out, err = call(r.comet.PackNumAssets())
+	if err != nil {
+		return nil, err
+	}
+	n, err := r.comet.UnpackNumAssets(out)
+	if err != nil {
+		return nil, err
+	}
+	for i := range n {
+		out, err := call(r.comet.PackGetAssetInfo(i))
+		if err != nil {
+			return nil, err
+		}
+		info, err := r.comet.UnpackGetAssetInfo(out)
+		if err != nil {
+			return nil, err
+		}
+		out, err = call(r.comet.PackCollateralBalanceOf(q.Account, info.Asset))
+		if err != nil {
+			return nil, err
+		}
+		amount, err := r.comet.UnpackAmount("collateralBalanceOf", out)
+		if err != nil {
+			return nil, err
+		}
+		if amount.Sign() > 0 {
+			positions = append(positions, Position{Protocol: r.Name(), Kind: PositionCollateral, Token: tokenFor(r.known, info.Asset), Amount: amount})
+		}
+	}
+	return positions, nil
+}
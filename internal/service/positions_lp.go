@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/tokens"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/uniswap"
+)
+
+// UniswapV2Reader values Uniswap V2 LP tokens as the account's share of the pair reserves.
+// Pairs are the configured tokens with lp "uniswap-v2".
+type UniswapV2Reader struct {
+	pair  *uniswap.PairV2
+	pairs []tokens.Token
+	known map[common.Address]tokens.Token
+}
+
+func NewUniswapV2Reader(pair *uniswap.PairV2, pairs, list []tokens.Token) *UniswapV2Reader {
+	return &UniswapV2Reader{pair: pair, pairs: pairs, known: knownTokens(list)}
+}
+
+func (r *UniswapV2Reader) Name() string { return "uniswap-v2" }
+
+func (r *UniswapV2Reader) Positions(ctx context.Context, q PositionQuery) ([]Position, error) {
+	var positions []Position
+	for _, p := range r.pairs {
+		ps, err := r.pairPositions(ctx, q, p)
+		if err != nil {
+			return nil, fmt.Errorf("pair %s: %w", p.Address, err)
+		}
+		positions = append(positions, ps...)
+	}
+	return positions, nil
+}
+
+func (r *UniswapV2Reader) pairPositions(ctx context.Context, q PositionQuery, p tokens.Token) ([]Position, error) {
+	addr := common.HexToAddress(p.Address)
+	call := func(data []byte, err error) ([]byte, error) {
+		if err != nil {
+			return nil, err
+		}
+		return q.Eth.Call(ctx, addr, data, q.Block)
+	}
+
+	balance, err := q.Eth.ERC20BalanceOf(ctx, addr, q.Account, q.Block)
+	if err != nil {
+		return nil, err
+	}
+	if balance.Sign() == 0 {
+		return nil, nil
+	}
+
+	out, err := call(r.pair.PackTotalSupply())
+	if err != nil {
+		return nil, err
+	}
+	supply, err := r.pair.UnpackTotalSupply(out)
+	if err != nil {
+		return nil, err
+	}
+	if supply.Sign() == 0 {
+		return nil, nil
+	}
+	out, err = call(r.pair.PackGetReserves())
+	if err != nil {
+		return nil, err
+	}
+	r0, r1, err := r.pair.UnpackGetReserves(out)
+	if err != nil {
+		return nil, err
+	}
+	out, err = call(r.pair.PackToken0())
+	if err != nil {
+		return nil, err
+	}
+	t0, err := r.pair.UnpackToken("token0", out)
+	if err != nil {
+		return nil, err
+	}
+	out, err = call(r.pair.PackToken1())
+	if err != nil {
+		return nil, err
+	}
+	t1, err := r.pair.UnpackToken("token1", out)
+	if err != nil {
+		return nil, err
+	}
+
+	market := p.Symbol
+	if market == "" {
+		market = addr.Hex()
+	}
+	// share of each reserve, rounded down like burn()
+	share := func(reserve *big.Int) *big.Int {
+		v := new(big.Int).Mul(reserve, balance)
+		return v.Div(v, supply)
+	}
+	var positions []Position
+	for _, leg := range []struct {
+		token   common.Address
+		reserve *big.Int
+	}{{t0, r0}, {t1, r1}} {
+		if amount := share(leg.reserve); amount.Sign() > 0 {
+			positions = append(positions, Position{Protocol: r.Name(), Market: market, Kind: PositionLP, Token: tokenFor(r.known, leg.token), Amount: amount})
+		}
+	}
+	return positions, nil
+}
+
+// UniswapV3Reader values the Uniswap V3 position NFTs of an account: the token amounts of each
+// position's liquidity at the current pool price, plus the fees not collected yet.
+type UniswapV3Reader struct {
+	manager *uniswap.PositionManager
+	pool    *uniswap.PoolV3
+	known   map[common.Address]tokens.Token
+}
+
+func NewUniswapV3Reader(manager *uniswap.PositionManager, pool *uniswap.PoolV3, list []tokens.Token) *UniswapV3Reader {
+	return &UniswapV3Reader{manager: manager, pool: pool, known: knownTokens(list)}
+}
+
+func (r *UniswapV3Reader) Name() string { return "uniswap-v3" }
+
+func (r *UniswapV3Reader) Positions(ctx context.Context, q PositionQuery) ([]Position, error) {
+	npm := r.manager.Address()
+	call := func(to common.Address, data []byte, err error) ([]byte, error) {
+		if err != nil {
+			return nil, err
+		}
+		return q.Eth.Call(ctx, to, data, q.Block)
+	}
+
+	data, err := r.manager.PackBalanceOf(q.Account)
+	out, err := call(npm, data, err)
+	if err != nil {
+		return nil, err
+	}
+	n, err := r.manager.UnpackUint("balanceOf", out)
+	if err != nil {
+		return nil, err
+	}
+	if n.Sign() == 0 {
+		return nil, nil
+	}
+
+	data, err = r.manager.PackFactory()
+	out, err = call(npm, data, err)
+	if err != nil {
+		return nil, err
+	}
+	factory, err := r.manager.UnpackAddress("factory", out)
+	if err != nil {
+		return nil, err
+	}
+
+	var positions []Position
+	for i := range n.Int64() {
+		data, err := r.manager.PackTokenOfOwnerByIndex(q.Account, i)
+		out, err := call(npm, data, err)
+		if err != nil {
+			return nil, err
+		}
+		id, err := r.manager.UnpackUint("tokenOfOwnerByIndex", out)
+		if err != nil {
+			return nil, err
+		}
+		ps, err := r.position(ctx, q, factory, id)
+		if err != nil {
+			return nil, fmt.Errorf("position #%s: %w", id, err)
+		}
+		positions = append(positions, ps...)
+	}
+	return positions, nil
+}
+
+func (r *UniswapV3Reader) position(ctx context.Context, q PositionQuery, factory common.Address, id *big.Int) ([]Position, error) {
+	call := func(to common.Address, data []byte, err error) ([]byte, error) {
+		if err != nil {
+			return nil, err
+		}
+		return q.Eth.Call(ctx, to, data, q.Block)
+	}
+
+	data, err := r.manager.PackPositions(id)
+	out, err := call(r.manager.Address(), data, err)
+	if err != nil {
+		return nil, err
+	}
+	p, err := r.manager.UnpackPositions(out)
+	if err != nil {
+		return nil, err
+	}
+	owed0, owed1 := p.TokensOwed0, p.TokensOwed1
+	if p.Liquidity.Sign() == 0 && owed0.Sign() == 0 && owed1.Sign() == 0 {
+		return nil, nil // closed position
+	}
+
+	amount0, amount1 := new(big.Int), new(big.Int)
+	fees0, fees1 := owed0, owed1
+	if p.Liquidity.Sign() > 0 {
+		data, err := r.manager.PackGetPool(p.Token0, p.Token1, p.Fee)
+		out, err := call(factory, data, err)
+		if err != nil {
+			return nil, err
+		}
+		pool, err := r.manager.UnpackAddress("getPool", out)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err = r.pool.PackSlot0()
+		out, err = call(pool, data, err)
+		if err != nil {
+			return nil, err
+		}
+		sqrtPrice, tick, err := r.pool.UnpackSlot0(out)
+		if err != nil {
+			return nil, err
+		}
+		amount0, amount1 = uniswap.AmountsForLiquidity(sqrtPrice, tick, p.TickLower, p.TickUpper, p.Liquidity)
+
+		// fee growth inside the range, as Pool.burn/collect would account it
+		var global [2]*big.Int
+		for token := range 2 {
+			data, err := r.pool.PackFeeGrowthGlobal(token)
+			out, err := call(pool, data, err)
+			if err != nil {
+				return nil, err
+			}
+			if global[token], err = r.pool.UnpackFeeGrowthGlobal(token, out); err != nil {
+				return nil, err
+			}
+		}
+		var outside [2][2]*big.Int // [lower|upper][token]
+		for j, t := range []int64{p.TickLower, p.TickUpper} {
+			data, err := r.pool.PackTicks(t)
+			out, err := call(pool, data, err)
+			if err != nil {
+				return nil, err
+			}
+			if outside[j][0], outside[j][1], err = r.pool.UnpackTicksFeeGrowthOutside(out); err != nil {
+				return nil, err
+			}
+		}
+		inside0 := uniswap.FeeGrowthInside(global[0], outside[0][0], outside[1][0], tick, p.TickLower, p.TickUpper)
+		inside1 := uniswap.FeeGrowthInside(global[1], outside[0][1], outside[1][1], tick, p.TickLower, p.TickUpper)
+		fees0 = uniswap.FeesOwed(p.Liquidity, inside0, p.FeeGrowthInside0Last, owed0)
+		fees1 = uniswap.FeesOwed(p.Liquidity, inside1, p.FeeGrowthInside1Last, owed1)
+	}
+
+	t0, t1 := tokenFor(r.known, p.Token0), tokenFor(r.known, p.Token1)
+	market := "#" + id.String()
+	var positions []Position
+	for _, leg := range []struct {
+		kind   string
+		token  tokens.Token
+		amount *big.Int
+	}{
+		{PositionLP, t0, amount0}, {PositionLP, t1, amount1},
+		{PositionFees, t0, fees0}, {PositionFees, t1, fees1},
+	} {
+		if leg.amount.Sign() > 0 {
+			positions = append(positions, Position{Protocol: r.Name(), Market: market, Kind: leg.kind, Token: leg.token, Amount: leg.amount})
+		}
+	}
+	return positions, nil
+}
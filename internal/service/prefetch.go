@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/chainlink"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/eth"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/tokens"
+)
+
+// prefetch batches every read ValueOne would make for accounts x toks through Multicall3
+// and returns a valuator whose client serves them from cache. On any batching problem
+// the affected reads are left to the regular per-call path.
+func (v *Valuator) prefetch(ctx context.Context, accounts []string, toks []tokens.Token) *Valuator {
+	calls, native, err := v.batchCalls(accounts, toks)
+	if err != nil {
+		v.log.Errorf("multicall: build batch: %v", err)
+		return v
+	}
+	cache, err := v.multicall.Prefetch(ctx, calls, native, v.block)
+	if err != nil {
+		v.log.Errorf("multicall: %v (falling back to per-call reads)", err)
+	}
+	if cache == nil {
+		return v
+	}
+	v.log.Infof("multicall: prefetched %d of %d reads", cache.Len(), len(calls)+len(native))
+
+	v.prefetchBounds(ctx, toks, cache)
+
+	c := *v
+	c.eth = v.eth.WithCache(cache)
+	return &c
+}
+
+// boundRounds is how many dependent steps prefetchBounds follows:
+// getFeed → aggregator() behind the proxy → minAnswer/maxAnswer.
+const boundRounds = 2
+
+// prefetchBounds batches the circuit-breaker reads of boundSources into cache. Each round
+// asks for the reads unlocked by the previous one; reads that failed are not retried.
+func (v *Valuator) prefetchBounds(ctx context.Context, toks []tokens.Token, cache *eth.CallCache) {
+	type key struct {
+		to   common.Address
+		data string
+	}
+	tried := make(map[key]bool)
+	for range boundRounds {
+		var next []eth.CallRequest
+		for _, t := range toks {
+			if chainlink.IsNative(t.Address) {
+				t = v.nativeToken(t)
+			} else if !common.IsHexAddress(t.Address) {
+				continue
+			}
+			for _, src := range v.sources {
+				bs, ok := src.(boundSource)
+				if !ok {
+					continue
+				}
+				calls, err := bs.BoundCalls(t, cache.Lookup)
+				if err != nil {
+					v.log.Errorf("multicall: build bound batch: %v", err)
+					return
+				}
+				for _, c := range calls {
+					if k := (key{c.To, string(c.Data)}); !tried[k] {
+						tried[k] = true
+						next = append(next, c)
+					}
+				}
+			}
+		}
+		if len(next) == 0 {
+			return
+		}
+		more, err := v.multicall.Prefetch(ctx, next, nil, v.block)
+		if err != nil {
+			v.log.Errorf("multicall: bounds: %v (falling back to per-call reads)", err)
+		}
+		if more == nil {
+			return
+		}
+		cache.Merge(more)
+	}
+}
+
+func (v *Valuator) batchCalls(accounts []string, toks []tokens.Token) ([]eth.CallRequest, []common.Address, error) {
+	var (
+		calls  []eth.CallRequest
+		native []common.Address
+		accs   = make([]common.Address, 0, len(accounts))
+	)
+	for _, a := range accounts {
+		if common.IsHexAddress(a) {
+			accs = append(accs, common.HexToAddress(a))
+		}
+	}
+
+	for _, t := range toks {
+		if chainlink.IsNative(t.Address) {
+			t = v.nativeToken(t)
+			native = append(native, accs...)
+		} else if common.IsHexAddress(t.Address) {
+			addr := common.HexToAddress(t.Address)
+			for _, acc := range accs {
+				data, err := v.eth.PackERC20BalanceOf(acc)
+				if err != nil {
+					return nil, nil, err
+				}
+				calls = append(calls, eth.CallRequest{To: addr, Data: data})
+			}
+			data, err := v.eth.PackERC20Decimals()
+			if err != nil {
+				return nil, nil, err
+			}
+			calls = append(calls, eth.CallRequest{To: addr, Data: data})
+			if t.Symbol == "" {
+				data, err := v.eth.PackERC20Symbol()
+				if err != nil {
+					return nil, nil, err
+				}
+				calls = append(calls, eth.CallRequest{To: addr, Data: data})
+			}
+		} else {
+			continue
+		}
+
+		feedCalls, err := v.priceCalls(t)
+		if err != nil {
+			return nil, nil, err
+		}
+		calls = append(calls, feedCalls...)
+	}
+	return calls, native, nil
+}
+
+// priceCalls collects the reads of every batchable price source.
+func (v *Valuator) priceCalls(t tokens.Token) ([]eth.CallRequest, error) {
+	var calls []eth.CallRequest
+	for _, src := range v.sources {
+		bs, ok := src.(batchSource)
+		if !ok {
+			continue
+		}
+		c, err := bs.Calls(t)
+		if err != nil {
+			return nil, err
+		}
+		calls = append(calls, c...)
+	}
+	return calls, nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/chainlink"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/eth"
+)
+
+// answerBounds reads minAnswer/maxAnswer of the aggregator at addr. Proxies don't expose
+// the bounds, so it follows aggregator() first and reads addr itself only when that fails.
+// The order matches boundCalls, so prefetched results cover every read.
+func answerBounds(ctx context.Context, q PriceQuery, agg *chainlink.AggregatorV3, addr common.Address) (*big.Int, *big.Int, error) {
+	data, err := agg.PackAggregator()
+	if err != nil {
+		return nil, nil, err
+	}
+	if out, err := q.Eth.Call(ctx, addr, data, q.Block); err == nil {
+		if underlying, err := agg.UnpackAggregator(out); err == nil {
+			return readBounds(ctx, q, agg, underlying)
+		}
+	}
+	return readBounds(ctx, q, agg, addr)
+}
+
+// boundCalls returns the next reads answerBounds needs for feed given the prefetched
+// results: aggregator() together with the feed's own bounds (in case it is not a proxy),
+// then the bounds of the aggregator behind it.
+func boundCalls(agg *chainlink.AggregatorV3, feed common.Address, cached func(common.Address, []byte) ([]byte, bool)) ([]eth.CallRequest, error) {
+	aggData, err := agg.PackAggregator()
+	if err != nil {
+		return nil, err
+	}
+	lo, err := agg.PackMinAnswer()
+	if err != nil {
+		return nil, err
+	}
+	hi, err := agg.PackMaxAnswer()
+	if err != nil {
+		return nil, err
+	}
+	out, ok := cached(feed, aggData)
+	if !ok {
+		return []eth.CallRequest{{To: feed, Data: aggData}, {To: feed, Data: lo}, {To: feed, Data: hi}}, nil
+	}
+	underlying, err := agg.UnpackAggregator(out)
+	if err != nil {
+		return nil, nil
+	}
+	return []eth.CallRequest{{To: underlying, Data: lo}, {To: underlying, Data: hi}}, nil
+}
+
+func readBounds(ctx context.Context, q PriceQuery, agg *chainlink.AggregatorV3, addr common.Address) (*big.Int, *big.Int, error) {
+	read := func(method string, pack func() ([]byte, error)) (*big.Int, error) {
+		data, err := pack()
+		if err != nil {
+			return nil, err
+		}
+		out, err := q.Eth.Call(ctx, addr, data, q.Block)
+		if err != nil {
+			return nil, err
+		}
+		return agg.UnpackAnswerBound(method, out)
+	}
+	lo, err := read("minAnswer", agg.PackMinAnswer)
+	if err != nil {
+		return nil, nil, err
+	}
+	hi, err := read("maxAnswer", agg.PackMaxAnswer)
+	if err != nil {
+		return nil, nil, err
+	}
+	return lo, hi, nil
+}
+
+// atBound reports an answer clamped to the aggregator's circuit-breaker range.
+func atBound(answer, lo, hi *big.Int) bool {
+	return answer.Cmp(lo) <= 0 || answer.Cmp(hi) >= 0
+}
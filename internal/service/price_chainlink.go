@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/chainlink"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/eth"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/tokens"
+	"github.com/dayanaadylkhanova/eth2usd/internal/entity"
+)
+
+// usdQuote is the USD denomination in the Chainlink Feed Registry.
+var usdQuote = chainlink.DenominationUSD
+
+// registryBase maps a token to its Feed Registry base address; native assets the
+// registry doesn't denominate (POL, BNB, ...) have none.
+func registryBase(t tokens.Token) (common.Address, bool) {
+	if chainlink.IsNative(t.Address) {
+		return chainlink.NativeAsset{Symbol: t.Symbol}.RegistryBase()
+	}
+	return common.HexToAddress(t.Address), true
+}
+
+// registryHops are the intermediate denominations tried when a token has no USD feed.
+var registryHops = []struct {
+	symbol string
+	addr   common.Address
+}{
+	{chainlink.ETH, chainlink.DenominationETH},
+	{chainlink.BTC, chainlink.DenominationBTC},
+}
+
+// RegistrySource prices tokens through the Chainlink Feed Registry (quote=USD).
+// Without a direct USD feed it derives the price as TOKEN/ETH*ETH/USD or TOKEN/BTC*BTC/USD.
+type RegistrySource struct {
+	feed *chainlink.FeedRegistry
+	agg  *chainlink.AggregatorV3 // reads bounds of the feeds behind the registry; nil disables the check
+}
+
+// NewRegistrySource with a non-nil agg also checks every answer against its aggregator's
+// minAnswer/maxAnswer, found via getFeed.
+func NewRegistrySource(feed *chainlink.FeedRegistry, agg *chainlink.AggregatorV3) *RegistrySource {
+	return &RegistrySource{feed: feed, agg: agg}
+}
+
+func (s *RegistrySource) Name() string { return "chainlink" }
+
+func (s *RegistrySource) Price(ctx context.Context, q PriceQuery) (entity.Price, error) {
+	base, ok := registryBase(q.Token)
+	if !ok {
+		return entity.Price{}, errNotApplicable
+	}
+	round, dec, err := s.round(ctx, q, base, usdQuote)
+	if err == nil {
+		p, err := chainlinkPrice(q.Token, round, dec)
+		if err != nil {
+			return entity.Price{}, err
+		}
+		if feed, ok := s.feedOf(ctx, q, base, usdQuote); ok {
+			p.AtBound = s.atBound(ctx, q, feed, round.Answer)
+		}
+		return p, nil
+	}
+	if ctx.Err() != nil {
+		return entity.Price{}, err
+	}
+
+	for _, hop := range registryHops {
+		if base == hop.addr {
+			continue
+		}
+		p, hopErr := s.route(ctx, q, base, hop.symbol, hop.addr)
+		if hopErr == nil {
+			return p, nil
+		}
+		if ctx.Err() != nil {
+			return entity.Price{}, hopErr
+		}
+	}
+	return entity.Price{}, err
+}
+
+// route prices base as base/hop * hop/USD with exact rational arithmetic.
+func (s *RegistrySource) route(ctx context.Context, q PriceQuery, base common.Address, hopSym string, hop common.Address) (entity.Price, error) {
+	r1, d1, bound1, err := s.leg(ctx, q, base, hop)
+	if err != nil {
+		return entity.Price{}, err
+	}
+	r2, d2, bound2, err := s.leg(ctx, q, hop, usdQuote)
+	if err != nil {
+		return entity.Price{}, err
+	}
+	if r1.Answer.Sign() <= 0 || r2.Answer.Sign() <= 0 {
+		return entity.Price{}, ErrNoPrice
+	}
+
+	price := new(big.Rat).Mul(scaledRat(r1.Answer, d1), scaledRat(r2.Answer, d2))
+
+	// a route is only as fresh as its older leg, and incomplete if either leg is
+	round := r1
+	if r2.UpdatedAt.Before(r1.UpdatedAt) {
+		round = r2
+	}
+
+	sym := strings.ToUpper(q.Token.Symbol)
+	if sym == "" {
+		sym = base.Hex()
+	}
+	return entity.Price{
+		Base:  q.Token.Symbol,
+		Quote: chainlink.USD,
+		// exact: a product of two fixed-point answers has a finite decimal expansion
+		Value:      DecimalFromRat(price).String(),
+		UpdatedAt:  round.UpdatedAt,
+		Source:     s.Name() + ":" + sym + "/" + hopSym + "*" + hopSym + "/" + chainlink.USD,
+		Round:      &round,
+		Incomplete: r1.Incomplete() || r2.Incomplete(),
+		AtBound:    bound1 || bound2,
+	}, nil
+}
+
+// leg reads one pair of a route. With the bound check on it resolves the pair's feed once
+// and reads both the round and the bounds from it; otherwise it asks the registry.
+func (s *RegistrySource) leg(ctx context.Context, q PriceQuery, base, quote common.Address) (entity.Round, uint8, bool, error) {
+	if s.agg == nil {
+		round, dec, err := s.round(ctx, q, base, quote)
+		return round, dec, false, err
+	}
+	feed, err := s.getFeed(ctx, q, base, quote)
+	if err != nil {
+		return entity.Round{}, 0, false, err
+	}
+	round, dec, err := proxyRound(ctx, q, s.agg, feed)
+	if err != nil {
+		return entity.Round{}, 0, false, err
+	}
+	return round, dec, s.atBound(ctx, q, feed, round.Answer), nil
+}
+
+// feedOf resolves the feed behind (base, quote) when the bound check is on.
+func (s *RegistrySource) feedOf(ctx context.Context, q PriceQuery, base, quote common.Address) (common.Address, bool) {
+	if s.agg == nil {
+		return common.Address{}, false
+	}
+	feed, err := s.getFeed(ctx, q, base, quote)
+	return feed, err == nil
+}
+
+func (s *RegistrySource) getFeed(ctx context.Context, q PriceQuery, base, quote common.Address) (common.Address, error) {
+	data, err := s.feed.PackGetFeed(base, quote)
+	if err != nil {
+		return common.Address{}, err
+	}
+	out, err := q.Eth.Call(ctx, s.feed.Address(), data, q.Block)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return s.feed.UnpackGetFeed(out)
+}
+
+// atBound checks answer against the bounds of the aggregator behind feed.
+// Unreadable bounds are treated as "not at bound".
+func (s *RegistrySource) atBound(ctx context.Context, q PriceQuery, feed common.Address, answer *big.Int) bool {
+	lo, hi, err := answerBounds(ctx, q, s.agg, feed)
+	if err != nil {
+		return false
+	}
+	return atBound(answer, lo, hi)
+}
+
+func (s *RegistrySource) Calls(t tokens.Token) ([]eth.CallRequest, error) {
+	base, ok := registryBase(t)
+	if !ok {
+		return nil, nil
+	}
+	decData, err := s.feed.PackDecimals(base, usdQuote)
+	if err != nil {
+		return nil, err
+	}
+	ld, err := s.feed.PackLatestRoundData(base, usdQuote)
+	if err != nil {
+		return nil, err
+	}
+	calls := []eth.CallRequest{{To: s.feed.Address(), Data: decData}, {To: s.feed.Address(), Data: ld}}
+	if s.agg != nil {
+		gf, err := s.feed.PackGetFeed(base, usdQuote)
+		if err != nil {
+			return nil, err
+		}
+		calls = append(calls, eth.CallRequest{To: s.feed.Address(), Data: gf})
+	}
+	return calls, nil
+}
+
+// BoundCalls follows getFeed(base, USD) from the prefetched results to the aggregator bounds.
+func (s *RegistrySource) BoundCalls(t tokens.Token, cached func(common.Address, []byte) ([]byte, bool)) ([]eth.CallRequest, error) {
+	if s.agg == nil {
+		return nil, nil
+	}
+	base, ok := registryBase(t)
+	if !ok {
+		return nil, nil
+	}
+	gf, err := s.feed.PackGetFeed(base, usdQuote)
+	if err != nil {
+		return nil, err
+	}
+	out, ok := cached(s.feed.Address(), gf)
+	if !ok {
+		return nil, nil
+	}
+	feed, err := s.feed.UnpackGetFeed(out)
+	if err != nil {
+		return nil, nil
+	}
+	return boundCalls(s.agg, feed, cached)
+}
+
+// round reads decimals and latestRoundData for (base, quote) from the Feed Registry.
+func (s *RegistrySource) round(ctx context.Context, q PriceQuery, base, quote common.Address) (entity.Round, uint8, error) {
+	// decimals(base, quote)
+	decData, err := s.feed.PackDecimals(base, quote)
+	if err != nil {
+		return entity.Round{}, 0, err
+	}
+	decOut, err := q.Eth.Call(ctx, s.feed.Address(), decData, q.Block)
+	if err != nil {
+		return entity.Round{}, 0, err
+	}
+	dec, err := s.feed.UnpackDecimals(decOut)
+	if err != nil {
+		return entity.Round{}, 0, err
+	}
+
+	// latestRoundData(base, quote)
+	ld, err := s.feed.PackLatestRoundData(base, quote)
+	if err != nil {
+		return entity.Round{}, 0, err
+	}
+	ldOut, err := q.Eth.Call(ctx, s.feed.Address(), ld, q.Block)
+	if err != nil {
+		return entity.Round{}, 0, err
+	}
+	round, err := s.feed.DecodeLatestRoundData(ldOut)
+	if err != nil {
+		return entity.Round{}, 0, err
+	}
+	return round, dec, nil
+}
+
+// AggregatorSource prices tokens that carry their own AggregatorV3 proxy ("feed" in the tokens file).
+type AggregatorSource struct {
+	agg         *chainlink.AggregatorV3
+	checkBounds bool
+}
+
+// NewAggregatorSource with checkBounds compares answers to the minAnswer/maxAnswer of
+// the aggregator behind the proxy.
+func NewAggregatorSource(agg *chainlink.AggregatorV3, checkBounds bool) *AggregatorSource {
+	return &AggregatorSource{agg: agg, checkBounds: checkBounds}
+}
+
+func (s *AggregatorSource) Name() string { return "chainlink-aggregator" }
+
+func (s *AggregatorSource) Price(ctx context.Context, q PriceQuery) (entity.Price, error) {
+	if q.Token.Feed == "" {
+		return entity.Price{}, errNotApplicable
+	}
+	if !common.IsHexAddress(q.Token.Feed) {
+		return entity.Price{}, configErrorf("feed address is not hex: %s", q.Token.Feed)
+	}
+	feed := common.HexToAddress(q.Token.Feed)
+	round, dec, err := proxyRound(ctx, q, s.agg, feed)
+	if err != nil {
+		return entity.Price{}, err
+	}
+	p, err := chainlinkPrice(q.Token, round, dec)
+	if err != nil {
+		return entity.Price{}, err
+	}
+	if s.checkBounds {
+		if lo, hi, err := answerBounds(ctx, q, s.agg, feed); err == nil {
+			p.AtBound = atBound(round.Answer, lo, hi)
+		}
+	}
+	return p, nil
+}
+
+func (s *AggregatorSource) Calls(t tokens.Token) ([]eth.CallRequest, error) {
+	if t.Feed == "" || !common.IsHexAddress(t.Feed) {
+		return nil, nil
+	}
+	to := common.HexToAddress(t.Feed)
+	decData, err := s.agg.PackDecimals()
+	if err != nil {
+		return nil, err
+	}
+	ld, err := s.agg.PackLatestRoundData()
+	if err != nil {
+		return nil, err
+	}
+	return []eth.CallRequest{{To: to, Data: decData}, {To: to, Data: ld}}, nil
+}
+
+// BoundCalls returns the bound reads of the token's feed not prefetched yet.
+func (s *AggregatorSource) BoundCalls(t tokens.Token, cached func(common.Address, []byte) ([]byte, bool)) ([]eth.CallRequest, error) {
+	if !s.checkBounds || t.Feed == "" || !common.IsHexAddress(t.Feed) {
+		return nil, nil
+	}
+	return boundCalls(s.agg, common.HexToAddress(t.Feed), cached)
+}
+
+// proxyRound reads decimals and latestRoundData directly from an AggregatorV3 proxy.
+func proxyRound(ctx context.Context, q PriceQuery, agg *chainlink.AggregatorV3, feed common.Address) (entity.Round, uint8, error) {
+	decData, err := agg.PackDecimals()
+	if err != nil {
+		return entity.Round{}, 0, err
+	}
+	decOut, err := q.Eth.Call(ctx, feed, decData, q.Block)
+	if err != nil {
+		return entity.Round{}, 0, err
+	}
+	dec, err := agg.UnpackDecimals(decOut)
+	if err != nil {
+		return entity.Round{}, 0, err
+	}
+
+	ld, err := agg.PackLatestRoundData()
+	if err != nil {
+		return entity.Round{}, 0, err
+	}
+	ldOut, err := q.Eth.Call(ctx, feed, ld, q.Block)
+	if err != nil {
+		return entity.Round{}, 0, err
+	}
+	round, err := agg.DecodeLatestRoundData(ldOut)
+	if err != nil {
+		return entity.Round{}, 0, err
+	}
+	return round, dec, nil
+}
+
+// scaledRat returns answer / 10^dec.
+func scaledRat(answer *big.Int, dec uint8) *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(dec)), nil)
+	return new(big.Rat).SetFrac(answer, scale)
+}
+
+// chainlinkPrice turns a round into a price; non-positive answers mean no price.
+func chainlinkPrice(t tokens.Token, round entity.Round, dec uint8) (entity.Price, error) {
+	if round.Answer == nil || round.Answer.Sign() <= 0 {
+		return entity.Price{}, ErrNoPrice
+	}
+	return entity.Price{
+		Base:      t.Symbol,
+		Quote:     chainlink.USD,
+		Value:     NewDecimal(round.Answer, int(dec)).String(),
+		UpdatedAt: round.UpdatedAt,
+		Round:     &round,
+	}, nil
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/eth"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/tokens"
+	"github.com/dayanaadylkhanova/eth2usd/internal/entity"
+)
+
+// errNotApplicable is returned by a source that has nothing configured for the token;
+// the chain skips it without reporting it as a failure.
+var errNotApplicable = errors.New("source not applicable")
+
+// PriceQuery is what a PriceSource needs to price one token.
+type PriceQuery struct {
+	Token tokens.Token
+	Eth   *eth.Client // may serve prefetched multicall results
+	Block *big.Int    // nil means latest
+
+	Rounding RoundingMode // for sources that can't print their price exactly
+}
+
+// PriceSource produces a USD price for a token.
+type PriceSource interface {
+	Name() string
+	Price(ctx context.Context, q PriceQuery) (entity.Price, error)
+}
+
+// batchSource is implemented by sources whose reads can be prefetched through Multicall3.
+type batchSource interface {
+	Calls(t tokens.Token) ([]eth.CallRequest, error)
+}
+
+// boundSource is implemented by sources whose circuit-breaker reads depend on results
+// of earlier reads (feed and aggregator addresses). BoundCalls returns the next reads
+// it needs given what has been prefetched so far; prefetch calls it round by round.
+type boundSource interface {
+	BoundCalls(t tokens.Token, cached func(to common.Address, data []byte) ([]byte, bool)) ([]eth.CallRequest, error)
+}
+
+// price walks the configured sources in order and returns the first usable price.
+// entity.Price.Source records which source produced it.
+func (v *Valuator) price(ctx context.Context, t tokens.Token) (entity.Price, error) {
+	return firstPrice(ctx, v.sources, PriceQuery{Token: t, Eth: v.eth, Block: v.block, Rounding: v.rounding})
+}
+
+func firstPrice(ctx context.Context, sources []PriceSource, q PriceQuery) (entity.Price, error) {
+	var failures []string
+	for _, src := range sources {
+		p, err := src.Price(ctx, q)
+		if err == nil {
+			if p.Source == "" {
+				p.Source = src.Name()
+			}
+			return p, nil
+		}
+		if errors.Is(err, errNotApplicable) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return entity.Price{}, ctx.Err()
+		}
+		// a misconfigured token is not "no price": report it as such right away
+		var cfgErr *ConfigError
+		if errors.As(err, &cfgErr) {
+			return entity.Price{}, err
+		}
+		failures = append(failures, src.Name()+": "+err.Error())
+	}
+	if len(failures) == 0 {
+		return entity.Price{}, ErrNoPrice
+	}
+	return entity.Price{}, fmt.Errorf("%w (%s)", ErrNoPrice, strings.Join(failures, "; "))
+}
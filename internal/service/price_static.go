@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/chainlink"
+	"github.com/dayanaadylkhanova/eth2usd/internal/entity"
+)
+
+// StaticSource serves fixed USD prices keyed by token address or symbol,
+// typically as the last resort of the chain.
+type StaticSource struct {
+	prices map[string]string
+}
+
+// NewStaticSource keys are matched case-insensitively against the token address first, then its symbol.
+func NewStaticSource(prices map[string]string) *StaticSource {
+	norm := make(map[string]string, len(prices))
+	for k, v := range prices {
+		norm[strings.ToLower(k)] = v
+	}
+	return &StaticSource{prices: norm}
+}
+
+func (s *StaticSource) Name() string { return "static" }
+
+func (s *StaticSource) Price(_ context.Context, q PriceQuery) (entity.Price, error) {
+	val, ok := s.prices[strings.ToLower(q.Token.Address)]
+	if !ok {
+		val, ok = s.prices[strings.ToLower(q.Token.Symbol)]
+	}
+	if !ok {
+		return entity.Price{}, errNotApplicable
+	}
+	return entity.Price{Base: q.Token.Symbol, Quote: chainlink.USD, Value: val}, nil
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/chainlink"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/tokens"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/uniswap"
+	"github.com/dayanaadylkhanova/eth2usd/internal/entity"
+)
+
+// twapPrecision is how many digits a TWAP price keeps: fractional ones, like Decimal.String,
+// or significant ones below 1.
+const twapPrecision = 18
+
+// UniswapSource prices a token from the time-weighted mean tick of its configured
+// Uniswap V3 pool, then converts the other pool token to USD through the quote sources.
+// WETH is priced as ETH, i.e. through the ETH/USD feed of ethToken.
+type UniswapSource struct {
+	pool   *uniswap.PoolV3
+	window time.Duration
+	weth   common.Address
+	known  map[common.Address]tokens.Token // configured tokens, to price the quote leg with their feeds
+	eth    tokens.Token                    // prices WETH
+	quotes []PriceSource
+}
+
+func NewUniswapSource(pool *uniswap.PoolV3, window time.Duration, weth string, ethToken tokens.Token, list []tokens.Token, quotes []PriceSource) *UniswapSource {
+	return &UniswapSource{
+		pool:   pool,
+		window: window,
+		weth:   common.HexToAddress(weth),
+		known:  knownTokens(list),
+		eth:    ethToken,
+		quotes: quotes,
+	}
+}
+
+func (s *UniswapSource) Name() string { return "uniswap-v3-twap" }
+
+func (s *UniswapSource) Price(ctx context.Context, q PriceQuery) (entity.Price, error) {
+	t := q.Token
+	if t.UniswapV3Pool == "" {
+		return entity.Price{}, errNotApplicable
+	}
+	if !common.IsHexAddress(t.UniswapV3Pool) || !common.IsHexAddress(t.Address) {
+		return entity.Price{}, configErrorf("uniswap: pool and token must be hex addresses")
+	}
+	window := s.window
+	if t.TWAPWindow != "" {
+		w, err := time.ParseDuration(t.TWAPWindow)
+		if err != nil {
+			return entity.Price{}, configErrorf("uniswap: twap_window: %w", err)
+		}
+		window = w
+	}
+	poolAddr := common.HexToAddress(t.UniswapV3Pool)
+	token := common.HexToAddress(t.Address)
+
+	// pool layout
+	token0, err := s.poolToken(ctx, q, poolAddr, "token0")
+	if err != nil {
+		return entity.Price{}, err
+	}
+	token1, err := s.poolToken(ctx, q, poolAddr, "token1")
+	if err != nil {
+		return entity.Price{}, err
+	}
+	var other common.Address
+	switch token {
+	case token0:
+		other = token1
+	case token1:
+		other = token0
+	default:
+		return entity.Price{}, configErrorf("uniswap: token is not in pool %s", poolAddr.Hex())
+	}
+
+	dec0, err := q.Eth.ERC20Decimals(ctx, token0, q.Block)
+	if err != nil {
+		return entity.Price{}, err
+	}
+	dec1, err := q.Eth.ERC20Decimals(ctx, token1, q.Block)
+	if err != nil {
+		return entity.Price{}, err
+	}
+
+	// mean tick over the window
+	data, err := s.pool.PackObserve(window)
+	if err != nil {
+		return entity.Price{}, err
+	}
+	out, err := q.Eth.Call(ctx, poolAddr, data, q.Block)
+	if err != nil {
+		return entity.Price{}, err
+	}
+	tick, err := s.pool.DecodeMeanTick(out, window)
+	if err != nil {
+		return entity.Price{}, err
+	}
+
+	// price of token in units of the other pool token, as an exact fraction
+	num, den := uniswap.TickRatio(tick, dec0, dec1)
+	if token == token1 {
+		num, den = den, num
+	}
+
+	// other token -> USD
+	quoteTok := s.quoteToken(other)
+	quote, err := firstPrice(ctx, s.quotes, PriceQuery{Token: quoteTok, Eth: q.Eth, Block: q.Block, Rounding: q.Rounding})
+	if err != nil {
+		return entity.Price{}, fmt.Errorf("uniswap: quote leg %s: %w", quoteTok.Symbol, err)
+	}
+	quoteUSD, err := ParseDecimal(quote.Value)
+	if err != nil {
+		return entity.Price{}, err
+	}
+	// 1.0001^tick has as many fractional digits as 4*|tick|: the only rounding is here,
+	// keeping twapPrecision significant digits for prices far below 1
+	r := quoteUSD.Rat()
+	num.Mul(num, r.Num())
+	den.Mul(den, r.Denom())
+	prec := twapPrecision
+	if lead := den.BitLen() - num.BitLen(); lead > 0 {
+		prec += lead*3/10 + 1 // log10(2) < 0.302
+	}
+	usd := quoRound(num, den, prec, q.Rounding)
+
+	// the price is only as fresh and as trustworthy as its weaker leg: the TWAP is current
+	// at the block, so the quote leg's update time, round and circuit-breaker state carry over
+	return entity.Price{
+		Base:       t.Symbol,
+		Quote:      chainlink.USD,
+		Value:      usd.String(),
+		Stale:      quote.Stale,
+		UpdatedAt:  quote.UpdatedAt,
+		Source:     s.Name() + "/" + quoteTok.Symbol + "*" + quote.Source,
+		Round:      quote.Round,
+		Incomplete: quote.Incomplete,
+		AtBound:    quote.AtBound,
+	}, nil
+}
+
+func (s *UniswapSource) poolToken(ctx context.Context, q PriceQuery, pool common.Address, method string) (common.Address, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if method == "token0" {
+		data, err = s.pool.PackToken0()
+	} else {
+		data, err = s.pool.PackToken1()
+	}
+	if err != nil {
+		return common.Address{}, err
+	}
+	out, err := q.Eth.Call(ctx, pool, data, q.Block)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return s.pool.UnpackToken(method, out)
+}
+
+// quoteToken maps the pool's other token to what the quote sources understand.
+func (s *UniswapSource) quoteToken(addr common.Address) tokens.Token {
+	if addr == s.weth {
+		return s.eth
+	}
+	if t, ok := s.known[addr]; ok {
+		return t
+	}
+	return tokens.Token{Address: addr.Hex(), Symbol: addr.Hex()}
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/chainlink"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/tokens"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/wrapper"
+	"github.com/dayanaadylkhanova/eth2usd/internal/entity"
+)
+
+// WrapperSource prices wrapped and yield-bearing tokens (ERC-4626 vaults, wstETH, rETH, cbETH)
+// as rate * underlying price, where the underlying is priced by the quote sources.
+// A token is a wrapper if its "rate" is set, if it is in wrapper.Known, or if it answers asset().
+// WETH is the 1:1 wrapper of ETH.
+type WrapperSource struct {
+	codec  *wrapper.Codec
+	weth   common.Address
+	eth    tokens.Token // underlying "eth://native" means ETH, even on chains with another native asset
+	known  map[common.Address]tokens.Token
+	quotes []PriceSource
+}
+
+func NewWrapperSource(codec *wrapper.Codec, weth string, ethToken tokens.Token, list []tokens.Token, quotes []PriceSource) *WrapperSource {
+	return &WrapperSource{
+		codec:  codec,
+		weth:   common.HexToAddress(weth),
+		eth:    ethToken,
+		known:  knownTokens(list),
+		quotes: quotes,
+	}
+}
+
+func (s *WrapperSource) Name() string { return "wrapper" }
+
+func (s *WrapperSource) Price(ctx context.Context, q PriceQuery) (entity.Price, error) {
+	t := q.Token
+	if !common.IsHexAddress(t.Address) {
+		return entity.Price{}, errNotApplicable
+	}
+	addr := common.HexToAddress(t.Address)
+
+	w, configured := wrapper.Wrapper{Rate: t.Rate, Underlying: t.Underlying}, t.Rate != ""
+	if !configured {
+		if addr == s.weth {
+			w, configured = wrapper.Wrapper{Rate: wrapper.OneToOne, Underlying: chainlink.ETHPseudoAddress}, true
+		} else if k, ok := wrapper.Known[addr]; ok {
+			w, configured = k, true
+		} else {
+			// unconfigured tokens are probed as ERC-4626 vaults
+			w.Rate = wrapper.ERC4626
+		}
+	}
+	if !wrapper.ValidRate(w.Rate) {
+		return entity.Price{}, configErrorf("wrapper: unknown rate %s", w.Rate)
+	}
+
+	rate, under, err := s.rate(ctx, q, addr, w)
+	if err != nil {
+		if !configured {
+			return entity.Price{}, errNotApplicable
+		}
+		return entity.Price{}, err
+	}
+
+	up, err := firstPrice(ctx, s.quotes, PriceQuery{Token: under, Eth: q.Eth, Block: q.Block, Rounding: q.Rounding})
+	if err != nil {
+		return entity.Price{}, fmt.Errorf("wrapper: underlying %s: %w", under.Symbol, err)
+	}
+	underUSD, err := ParseDecimal(up.Value)
+	if err != nil {
+		return entity.Price{}, err
+	}
+
+	return entity.Price{
+		Base:      t.Symbol,
+		Quote:     chainlink.USD,
+		Value:     rate.Mul(underUSD).String(),
+		UpdatedAt: up.UpdatedAt,
+		// e.g. wrapper:erc4626/DAI@1.052341*chainlink
+		Source:     s.Name() + ":" + w.Rate + "/" + under.Symbol + "@" + rate.StringFixed(6, RoundHalfEven) + "*" + up.Source,
+		Round:      up.Round,
+		Incomplete: up.Incomplete,
+		AtBound:    up.AtBound,
+	}, nil
+}
+
+// rate returns how many underlying tokens one wrapper token is worth, and the underlying.
+func (s *WrapperSource) rate(ctx context.Context, q PriceQuery, addr common.Address, w wrapper.Wrapper) (Decimal, tokens.Token, error) {
+	if w.Rate == wrapper.OneToOne {
+		under, err := s.underlying(ctx, q, w.Underlying)
+		return DecimalFromRat(big.NewRat(1, 1)), under, err
+	}
+	if w.Rate != wrapper.ERC4626 {
+		data, err := s.codec.PackRate(w.Rate)
+		if err != nil {
+			return Decimal{}, tokens.Token{}, err
+		}
+		out, err := q.Eth.Call(ctx, addr, data, q.Block)
+		if err != nil {
+			return Decimal{}, tokens.Token{}, err
+		}
+		raw, err := s.codec.UnpackUint(w.Rate, out)
+		if err != nil {
+			return Decimal{}, tokens.Token{}, err
+		}
+		under, err := s.underlying(ctx, q, w.Underlying)
+		if err != nil {
+			return Decimal{}, tokens.Token{}, err
+		}
+		return DecimalFromRat(new(big.Rat).SetFrac(raw, wrapper.RateScale)), under, nil
+	}
+
+	// ERC-4626: assets of one whole share, in underlying units
+	underAddr := w.Underlying
+	if underAddr == "" {
+		data, err := s.codec.PackAsset()
+		if err != nil {
+			return Decimal{}, tokens.Token{}, err
+		}
+		out, err := q.Eth.Call(ctx, addr, data, q.Block)
+		if err != nil {
+			return Decimal{}, tokens.Token{}, err
+		}
+		asset, err := s.codec.UnpackAsset(out)
+		if err != nil {
+			return Decimal{}, tokens.Token{}, err
+		}
+		underAddr = asset.Hex()
+	}
+	under, err := s.underlying(ctx, q, underAddr)
+	if err != nil {
+		return Decimal{}, tokens.Token{}, err
+	}
+
+	shareDec, err := q.Eth.ERC20Decimals(ctx, addr, q.Block)
+	if err != nil {
+		return Decimal{}, tokens.Token{}, err
+	}
+	data, err := s.codec.PackConvertToAssets(pow10(int(shareDec)))
+	if err != nil {
+		return Decimal{}, tokens.Token{}, err
+	}
+	out, err := q.Eth.Call(ctx, addr, data, q.Block)
+	if err != nil {
+		return Decimal{}, tokens.Token{}, err
+	}
+	assets, err := s.codec.UnpackUint("convertToAssets", out)
+	if err != nil {
+		return Decimal{}, tokens.Token{}, err
+	}
+	return NewDecimal(assets, under.Decimals), under, nil
+}
+
+// underlying describes the underlying asset for the quote sources, reading its
+// decimals and symbol on-chain when it is not a configured token.
+func (s *WrapperSource) underlying(ctx context.Context, q PriceQuery, addr string) (tokens.Token, error) {
+	if chainlink.IsNative(addr) {
+		return s.eth, nil
+	}
+	if !common.IsHexAddress(addr) {
+		return tokens.Token{}, configErrorf("wrapper: underlying is not hex: %s", addr)
+	}
+	a := common.HexToAddress(addr)
+	t, ok := s.known[a]
+	if !ok {
+		t = tokens.Token{Address: a.Hex()}
+	}
+	if t.Decimals == 0 {
+		dec, err := q.Eth.ERC20Decimals(ctx, a, q.Block)
+		if err != nil {
+			return tokens.Token{}, err
+		}
+		t.Decimals = int(dec)
+	}
+	if t.Symbol == "" {
+		if sym, err := q.Eth.ERC20Symbol(ctx, a, q.Block); err == nil && sym != "" {
+			t.Symbol = sym
+		} else {
+			t.Symbol = a.Hex()
+		}
+	}
+	return t, nil
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/chainlink"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/tokens"
+)
+
+// WithQuote returns a copy of the valuator that converts USD results into 'symbol',
+// pricing the quote currency like any other token (e.g. EUR via the registry's EUR/USD feed).
+func (v *Valuator) WithQuote(symbol string, t tokens.Token) *Valuator {
+	c := *v
+	c.quote = symbol
+	c.quoteToken = t
+	return &c
+}
+
+// convert fills the quote-denominated fields of res from its USD fields. The quote price
+// goes through the same staleness, round and bound checks as a row's; its problem, if any,
+// is reported in QuoteErr/QuoteClass. A quote that can't be priced fails the whole run.
+func (v *Valuator) convert(ctx context.Context, res *ValuationResult) error {
+	p, err := v.price(ctx, v.quoteToken)
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		return NewFailure(Classify(err), fmt.Errorf("quote %s: %w", v.quote, err))
+	}
+	rate, err := ParseDecimal(p.Value)
+	if err != nil || rate.Sign() <= 0 {
+		return NewFailure(FailNoPrice, fmt.Errorf("quote %s: bad price %q", v.quote, p.Value))
+	}
+	stale, quoteErr, quoteClass, err := v.checkPrice(v.quoteToken, p, rate)
+	if err != nil {
+		return NewFailure(Classify(err), fmt.Errorf("quote %s: %w", v.quote, err))
+	}
+	prec := quotePrecision(v.quote)
+	toQuote := func(usd Decimal) string {
+		q, _ := usd.Quo(rate) // rate is positive
+		return q.StringFixed(prec, v.rounding)
+	}
+
+	res.Quote = v.quote
+	res.QuoteUSD = p.Value
+	res.QuoteSource = p.Source
+	if stale {
+		res.QuoteSource += ":stale"
+	}
+	res.QuoteErr, res.QuoteClass = quoteErr, quoteClass
+	for i := range res.Rows {
+		res.Rows[i].Value = toQuote(res.Rows[i].usd)
+	}
+	for i := range res.Accounts {
+		res.Accounts[i].Total = toQuote(res.Accounts[i].usd)
+	}
+	res.Total = toQuote(res.totalUSD)
+	if len(res.NFTs) > 0 {
+		for i := range res.NFTs {
+			res.NFTs[i].Value = toQuote(res.NFTs[i].usd)
+		}
+		res.NFTTotal = toQuote(res.nftUSD)
+	}
+	return nil
+}
+
+// quotePrecision keeps cents for fiat and more digits for crypto denominations.
+func quotePrecision(symbol string) int {
+	switch symbol {
+	case chainlink.ETH, chainlink.BTC:
+		return 8
+	default:
+		return 2
+	}
+}
@@ -4,47 +4,213 @@ import (
 	"context"
 	"errors"
 	"math/big"
+	"strings"
 	"time"
 
-	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 
 	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/chainlink"
 	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/eth"
+	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/nft"
 	"github.com/dayanaadylkhanova/eth2usd/internal/adapter/tokens"
+	"github.com/dayanaadylkhanova/eth2usd/internal/entity"
 	"github.com/dayanaadylkhanova/eth2usd/pkg/logger"
 )
 
 // Valuator coordinates on-chain reads and pricing to produce valuation rows.
 type Valuator struct {
-	log  *logger.Logger
-	eth  *eth.Client
-	feed *chainlink.FeedRegistry
+	log     *logger.Logger
+	eth     *eth.Client
+	sources []PriceSource // tried in order until one yields a price
+
+	block *big.Int  // nil means latest
+	asOf  time.Time // reference time for staleness; zero means now
+
+	multicall   *eth.Multicall // optional batch reader
+	concurrency int            // parallel ValueOne calls in ValueAccounts; <=1 means sequential
+
+	quote      string       // output denomination other than USD, "" means USD only
+	quoteToken tokens.Token // how to price one unit of quote in USD
+
+	rounding  RoundingMode  // applied when printing amounts, values and totals
+	heartbeat time.Duration // default max price age; tokens may override
+
+	native chainlink.NativeAsset // what "eth://native" means on the connected chain
+
+	positions []PositionReader // lending protocols read for every account
+
+	nftCodec *nft.Codec
+	nfts     []nft.Collection             // NFT collections listed for every account
+	floors   map[common.Address]nft.Floor // floor prices by collection
+	nftETH   tokens.Token                 // prices ETH floors
+}
+
+func NewValuator(log *logger.Logger, ethc *eth.Client, sources []PriceSource) *Valuator {
+	return &Valuator{log: log, eth: ethc, sources: sources, native: chainlink.NativeETH}
+}
+
+// AtBlock returns a copy of the valuator that performs every read at the given block
+// and measures price staleness against the block timestamp.
+func (v *Valuator) AtBlock(number *big.Int, at time.Time) *Valuator {
+	c := *v
+	c.block = number
+	c.asOf = at
+	return &c
 }
 
-func NewValuator(log *logger.Logger, ethc *eth.Client, feed *chainlink.FeedRegistry) *Valuator {
-	return &Valuator{log: log, eth: ethc, feed: feed}
+// WithMulticall returns a copy of the valuator that prefetches all reads of
+// ValueAccounts through Multicall3 before valuing rows.
+func (v *Valuator) WithMulticall(m *eth.Multicall) *Valuator {
+	c := *v
+	c.multicall = m
+	return &c
+}
+
+// WithConcurrency returns a copy of the valuator that values up to n tokens in parallel.
+func (v *Valuator) WithConcurrency(n int) *Valuator {
+	c := *v
+	c.concurrency = n
+	return &c
+}
+
+// WithRounding returns a copy of the valuator that prints amounts and values with mode.
+func (v *Valuator) WithRounding(mode RoundingMode) *Valuator {
+	c := *v
+	c.rounding = mode
+	return &c
+}
+
+// WithNative returns a copy of the valuator that values native balances as asset n.
+func (v *Valuator) WithNative(n chainlink.NativeAsset) *Valuator {
+	c := *v
+	c.native = n
+	return &c
+}
+
+// nativeToken fills the symbol, decimals and feed of a native token entry from the
+// chain's native asset; fields set in the tokens file win.
+func (v *Valuator) nativeToken(t tokens.Token) tokens.Token {
+	if t.Symbol == "" {
+		t.Symbol = v.native.Symbol
+	}
+	if t.Decimals == 0 {
+		t.Decimals = int(v.native.Decimals)
+	}
+	if t.Feed == "" {
+		t.Feed = v.native.Feed
+	}
+	return t
+}
+
+// DefaultHeartbeat is the max price age when neither the token nor the valuator set one.
+const DefaultHeartbeat = 24 * time.Hour
+
+// WithHeartbeat returns a copy of the valuator that flags prices older than d as stale.
+func (v *Valuator) WithHeartbeat(d time.Duration) *Valuator {
+	c := *v
+	c.heartbeat = d
+	return &c
+}
+
+// heartbeatFor resolves the staleness threshold of a token.
+func (v *Valuator) heartbeatFor(t tokens.Token) (time.Duration, error) {
+	if t.Heartbeat != "" {
+		d, err := time.ParseDuration(t.Heartbeat)
+		if err != nil {
+			return 0, configErrorf("token %s heartbeat: %w", t.Symbol, err)
+		}
+		return d, nil
+	}
+	if v.heartbeat > 0 {
+		return v.heartbeat, nil
+	}
+	return DefaultHeartbeat, nil
+}
+
+func (v *Valuator) workers() int {
+	if v.concurrency < 1 {
+		return 1
+	}
+	return v.concurrency
+}
+
+func (v *Valuator) now() time.Time {
+	if v.asOf.IsZero() {
+		return time.Now()
+	}
+	return v.asOf
 }
 
 type ValuationRow struct {
-	Symbol string
-	Amount string // human amount
-	USD    string // human usd
-	Source string // "chainlink" | "chainlink:stale" | "error"
-	Err    string // optional error message for the row
+	Account string
+	Symbol  string
+	Amount  string // human amount
+	USD     string // human usd
+	Value   string `json:",omitempty"` // human value in the quote currency, see ValuationResult.Quote
+	Price   string `json:",omitempty"` // USD price used for the row
+
+	UpdatedAt time.Time `json:",omitzero"`  // price round update time
+	RoundID   string    `json:",omitempty"` // Chainlink round id behind the price
+
+	Position string `json:",omitempty"` // protocol position, e.g. "aave-v3 borrow"; amounts of borrows are negative
+	Token    string `json:",omitempty"` // token contract (or native pseudo-address), see tokenKey; tells apart tokens sharing a symbol
+
+	Source   string       // price source name (+ ":stale") | "none" | "error"
+	Err      string       // optional error message for the row
+	ErrClass FailureClass `json:",omitempty"`
+
+	usd       Decimal // exact USD value behind USD
+	zero      bool    // balance is exactly zero
+	liability bool    // a borrow, or a failed position read that may hide one
+}
+
+// tokenKey identifies a token regardless of how its address was written.
+func tokenKey(t tokens.Token) string {
+	if common.IsHexAddress(t.Address) {
+		return common.HexToAddress(t.Address).Hex()
+	}
+	return strings.ToLower(t.Address)
+}
+
+// AccountTotal is the USD sum of the valid rows of a single account.
+type AccountTotal struct {
+	Account    string
+	TotalUSD   string
+	Total      string `json:",omitempty"` // in the quote currency
+	Incomplete bool   `json:",omitempty"` // a debt row failed, so the net total may be overstated
+
+	usd Decimal
 }
 
 type ValuationResult struct {
-	Rows     []ValuationRow
-	TotalUSD string
+	Block       uint64       `json:",omitzero"`
+	BlockTime   time.Time    `json:",omitzero"`
+	Quote       string       `json:",omitempty"` // quote currency of Value/Total, empty means USD only
+	QuoteUSD    string       `json:",omitempty"` // USD price of one unit of Quote
+	QuoteSource string       `json:",omitempty"`
+	QuoteErr    string       `json:",omitempty"` // problem with the quote price itself, like a row's Err
+	QuoteClass  FailureClass `json:",omitempty"`
+	Rows        []ValuationRow
+	Accounts    []AccountTotal
+	TotalUSD    string
+	Total       string `json:",omitempty"`
+	Incomplete  bool   `json:",omitempty"` // some account total is incomplete
+
+	// NFTs are valued at floor prices and kept out of the totals above
+	NFTs        []NFTHolding `json:",omitempty"`
+	NFTTotalUSD string       `json:",omitempty"`
+	NFTTotal    string       `json:",omitempty"` // in the quote currency
+
+	totalUSD Decimal
+	nftUSD   Decimal
 }
 
-// ValueOne reads the balance for a token, fetches its USD price via Chainlink Feed Registry,
+// ValueOne reads the balance for a token, fetches its USD price from the source chain,
 // and returns a formatted valuation row.
 func (v *Valuator) ValueOne(ctx context.Context, account string, t tokens.Token) (ValuationRow, error) {
 	// Validate account
 	if !common.IsHexAddress(account) {
-		return ValuationRow{}, errors.New("invalid --account address")
+		return ValuationRow{}, configErrorf("invalid account address %q", account)
 	}
 	acc := common.HexToAddress(account)
 
@@ -55,29 +221,30 @@ func (v *Valuator) ValueOne(ctx context.Context, account string, t tokens.Token)
 		decimals uint8
 	)
 
-	if t.Address == chainlink.ETHPseudoAddress {
-		// Native ETH
-		bal, err := v.eth.GetBalance(ctx, acc)
+	if chainlink.IsNative(t.Address) {
+		// Native asset of the chain (ETH, POL, BNB, ...)
+		t = v.nativeToken(t)
+		bal, err := v.eth.GetBalance(ctx, acc, v.block)
 		if err != nil {
 			return ValuationRow{}, err
 		}
 		raw = bal
-		sym = "ETH"
-		decimals = 18
+		sym = t.Symbol
+		decimals = uint8(t.Decimals)
 	} else {
 		// ERC-20
 		if !common.IsHexAddress(t.Address) {
-			return ValuationRow{}, errors.New("token address is not hex: " + t.Address)
+			return ValuationRow{}, configErrorf("token address is not hex: %s", t.Address)
 		}
 		addr := common.HexToAddress(t.Address)
 
-		bal, err := v.eth.ERC20BalanceOf(ctx, addr, acc)
+		bal, err := v.eth.ERC20BalanceOf(ctx, addr, acc, v.block)
 		if err != nil {
 			return ValuationRow{}, err
 		}
 		raw = bal
 
-		dec, err := v.eth.ERC20Decimals(ctx, addr)
+		dec, err := v.eth.ERC20Decimals(ctx, addr, v.block)
 		if err != nil {
 			return ValuationRow{}, err
 		}
@@ -85,89 +252,129 @@ func (v *Valuator) ValueOne(ctx context.Context, account string, t tokens.Token)
 
 		if t.Symbol != "" {
 			sym = t.Symbol
-		} else if s, err := v.eth.ERC20Symbol(ctx, addr); err == nil && s != "" {
+		} else if s, err := v.eth.ERC20Symbol(ctx, addr, v.block); err == nil && s != "" {
 			sym = s
 		} else {
 			sym = "TKN"
 		}
 	}
 
-	// Pre-format human-readable amount (even if price is missing we can return this)
-	amountHuman := FormatAmount(raw, int(decimals), 6)
+	return v.valueAmount(ctx, acc, t, sym, raw, decimals)
+}
 
-	// 2) Price via Chainlink Feed Registry (base, quote=USD)
-	var base common.Address
-	if t.Address == chainlink.ETHPseudoAddress {
-		// Native token placeholder used by Chainlink registry on mainnet
-		base = common.HexToAddress("0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE")
-	} else {
-		base = common.HexToAddress(t.Address)
-	}
-	quote := common.HexToAddress("0x0000000000000000000000000000000000000348") // USD
+// valueAmount prices a raw amount of t (negative for debt) and builds its row.
+func (v *Valuator) valueAmount(ctx context.Context, acc common.Address, t tokens.Token, sym string, raw *big.Int, decimals uint8) (ValuationRow, error) {
+	// Pre-format human-readable amount (even if price is missing we can return this)
+	amount := NewDecimal(raw, int(decimals))
+	amountHuman := amount.StringFixed(6, v.rounding)
 
-	// decimals(base, quote)
-	decData, err := v.feed.PackDecimals(base, quote)
-	if err != nil {
-		return ValuationRow{}, err
-	}
-	decOut, err := v.eth.Eth.CallContract(ctx, ethereum.CallMsg{To: ptr(v.feed.Address()), Data: decData}, nil)
-	if err != nil {
-		return ValuationRow{}, err
-	}
-	priceDecimals, err := v.feed.UnpackDecimals(decOut)
+	// 2) Price via the first source of the chain that has one
+	price, err := v.price(ctx, t)
 	if err != nil {
+		if errors.Is(err, ErrNoPrice) {
+			// keep the amount, the row just can't be valued
+			return ValuationRow{
+				Account:  acc.Hex(),
+				Symbol:   sym,
+				Amount:   amountHuman,
+				USD:      "0",
+				Source:   "none",
+				Err:      err.Error(),
+				ErrClass: FailNoPrice,
+				zero:     raw.Sign() == 0,
+				Token:    tokenKey(t),
+			}, nil
+		}
 		return ValuationRow{}, err
 	}
 
-	// latestRoundData(base, quote)
-	ld, err := v.feed.PackLatestRoundData(base, quote)
+	// 3) Compute USD = amount * price, exactly; rounding happens only for display
+	priceDec, err := ParseDecimal(price.Value)
 	if err != nil {
 		return ValuationRow{}, err
 	}
-	ldOut, err := v.eth.Eth.CallContract(ctx, ethereum.CallMsg{To: ptr(v.feed.Address()), Data: ld}, nil)
+	usd := amount.Mul(priceDec)
+
+	// 4) Staleness, round completeness and sanity bounds
+	stale, rowErr, errClass, err := v.checkPrice(t, price, priceDec)
 	if err != nil {
 		return ValuationRow{}, err
 	}
-	answer, updatedAt, err := v.feed.DecodeLatestRoundData(ldOut)
-	if err != nil {
-		return ValuationRow{}, err
+	source := price.Source
+	if stale {
+		source += ":stale"
 	}
 
-	// 3) Validate price and staleness
-	if answer == nil || answer.Sign() <= 0 {
-		// No price available
-		return ValuationRow{
-			Symbol: sym,
-			Amount: amountHuman,
-			USD:    "0",
-			Source: "chainlink",
-			Err:    ErrNoPrice.Error(),
-		}, nil
+	var roundID string
+	if price.Round != nil {
+		roundID = price.Round.RoundID.String()
 	}
 
-	stale := time.Since(updatedAt) > 24*time.Hour
-
-	// 4) Compute USD = amount * price
-	priceHuman := FormatAmount(answer, int(priceDecimals), 8)
-	usd := MulDecimalStrings(amountHuman, priceHuman, 2)
+	return ValuationRow{
+		Account:   acc.Hex(),
+		Symbol:    sym,
+		Amount:    amountHuman,
+		USD:       usd.StringFixed(2, v.rounding),
+		Price:     priceDec.String(),
+		UpdatedAt: price.UpdatedAt,
+		RoundID:   roundID,
+		Source:    source,
+		Err:       rowErr,
+		ErrClass:  errClass,
+		usd:       usd,
+		zero:      raw.Sign() == 0,
+		Token:     tokenKey(t),
+	}, nil
+}
 
-	source := "chainlink"
-	if stale {
-		source = "chainlink:stale"
+// checkPrice reports whether price of t is stale against the token's heartbeat (sources
+// without timestamps never are) and returns the most severe problem with it, if any:
+// an answer at the feed's circuit breaker or outside the token's own range, then an
+// incomplete round, then staleness.
+func (v *Valuator) checkPrice(t tokens.Token, price entity.Price, value Decimal) (bool, string, FailureClass, error) {
+	heartbeat, err := v.heartbeatFor(t)
+	if err != nil {
+		return false, "", "", err
 	}
+	stale := !price.UpdatedAt.IsZero() && v.now().Sub(price.UpdatedAt) > heartbeat
 
-	rowErr := ""
-	if stale {
-		rowErr = ErrStalePrice.Error()
+	outOfRange, err := outsideBounds(t, value)
+	if err != nil {
+		return false, "", "", err
 	}
 
-	return ValuationRow{
-		Symbol: sym,
-		Amount: amountHuman,
-		USD:    usd,
-		Source: source,
-		Err:    rowErr,
-	}, nil
+	switch {
+	case price.AtBound:
+		return stale, ErrPriceAtBound.Error(), FailBound, nil
+	case outOfRange:
+		return stale, ErrPriceOutOfRange.Error(), FailBound, nil
+	case price.Incomplete || price.Round.Incomplete():
+		return stale, ErrIncompleteRound.Error(), FailIncomplete, nil
+	case stale:
+		return stale, ErrStalePrice.Error(), FailStale, nil
+	}
+	return false, "", "", nil
 }
 
-func ptr(a common.Address) *common.Address { return &a }
+// outsideBounds checks price against the token's optional min_price/max_price.
+func outsideBounds(t tokens.Token, price Decimal) (bool, error) {
+	if t.MinPrice != "" {
+		lo, err := ParseDecimal(t.MinPrice)
+		if err != nil {
+			return false, configErrorf("token %s min_price: %w", t.Symbol, err)
+		}
+		if price.Cmp(lo) < 0 {
+			return true, nil
+		}
+	}
+	if t.MaxPrice != "" {
+		hi, err := ParseDecimal(t.MaxPrice)
+		if err != nil {
+			return false, configErrorf("token %s max_price: %w", t.Symbol, err)
+		}
+		if price.Cmp(hi) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}